@@ -0,0 +1,241 @@
+package archive
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot 是一段内容相同的连续响应，相同内容只保存一份
+type Snapshot struct {
+	Hash      string    `json:"hash"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int       `json:"count"`
+	Size      int       `json:"size"`
+	// Server 是给出响应的服务器，不同服务器的响应格式不同，只与同一服务器的响应比较
+	Server string `json:"server,omitempty"`
+}
+
+var mu sync.Mutex
+
+// domainDir 返回域名的存档目录，使用 A-label 避免文件名中出现 Unicode 字符
+func domainDir(domain string) string {
+	return config.GetConfigPath(filepath.Join("archive", domainname.ToASCII(domain)))
+}
+
+func indexPath(domain string) string {
+	return filepath.Join(domainDir(domain), "index.json")
+}
+
+func blobPath(domain, hash string) string {
+	return filepath.Join(domainDir(domain), hash+".gz")
+}
+
+// Store 保存一次查询的原始响应，返回同一服务器上一次不同的响应内容。
+// 内容与上一次相同时只更新时间和次数；同一服务器没有更早的响应时 changed 同样为 false。
+func Store(domain, server, response string, at time.Time, retentionDays int) (previous string, changed bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	snapshots, err := loadIndex(domain)
+	if err != nil {
+		return "", false, err
+	}
+
+	hash := contentHash(response)
+
+	if n := len(snapshots); n > 0 && snapshots[n-1].Hash == hash && snapshots[n-1].Server == server {
+		snapshots[n-1].LastSeen = at
+		snapshots[n-1].Count++
+	} else {
+		if last := lastFrom(snapshots, server); last != nil && last.Hash != hash {
+			if previous, err = loadBlob(domain, last.Hash); err != nil {
+				return "", false, err
+			}
+			changed = true
+		}
+		if err := writeBlob(domain, hash, response); err != nil {
+			return "", false, err
+		}
+		snapshots = append(snapshots, Snapshot{
+			Hash:      hash,
+			FirstSeen: at,
+			LastSeen:  at,
+			Count:     1,
+			Size:      len(response),
+			Server:    server,
+		})
+	}
+
+	snapshots = prune(domain, snapshots, at, retentionDays)
+	return previous, changed, saveIndex(domain, snapshots)
+}
+
+// lastFrom 返回指定服务器最近的一份存档
+func lastFrom(snapshots []Snapshot, server string) *Snapshot {
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Server == server {
+			return &snapshots[i]
+		}
+	}
+	return nil
+}
+
+// 每次查询都会变化的行，计算哈希时忽略，避免相同内容被重复保存
+func volatileMarkers() []string {
+	return []string{
+		">>> last update of whois database",
+		"last update of rdap database",
+		"query time",
+		"timestamp",
+	}
+}
+
+// contentHash 计算去除易变行后的内容哈希
+func contentHash(response string) string {
+	h := sha256.New()
+	for _, line := range strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n") {
+		lower := strings.ToLower(line)
+		volatile := false
+		for _, marker := range volatileMarkers() {
+			if strings.Contains(lower, marker) {
+				volatile = true
+				break
+			}
+		}
+		if !volatile {
+			h.Write([]byte(line + "\n"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// List 返回域名的所有存档，按时间从早到晚排列
+func List(domain string) ([]Snapshot, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return loadIndex(domain)
+}
+
+// Load 读取指定哈希的响应内容
+func Load(domain, hash string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	snapshots, err := loadIndex(domain)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range snapshots {
+		if s.Hash == hash {
+			return loadBlob(domain, hash)
+		}
+	}
+	return "", fmt.Errorf("未找到域名 %s 的存档 %s", domain, hash)
+}
+
+// prune 删除超过保留期的存档，但始终保留最新的一份
+func prune(domain string, snapshots []Snapshot, now time.Time, retentionDays int) []Snapshot {
+	if retentionDays <= 0 || len(snapshots) <= 1 {
+		return snapshots
+	}
+
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	kept := make([]Snapshot, 0, len(snapshots))
+	for i, s := range snapshots {
+		if s.LastSeen.Before(cutoff) && i < len(snapshots)-1 {
+			continue
+		}
+		kept = append(kept, s)
+	}
+
+	// 同一内容可能在多段记录中出现，只删除不再被引用的文件
+	referenced := make(map[string]bool, len(kept))
+	for _, s := range kept {
+		referenced[s.Hash] = true
+	}
+	for _, s := range snapshots {
+		if !referenced[s.Hash] {
+			os.Remove(blobPath(domain, s.Hash))
+			referenced[s.Hash] = true
+		}
+	}
+	return kept
+}
+
+func loadIndex(domain string) ([]Snapshot, error) {
+	content, err := os.ReadFile(indexPath(domain))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	if err := json.Unmarshal(content, &snapshots); err != nil {
+		return nil, fmt.Errorf("解析域名 %s 的存档索引失败: %w", domain, err)
+	}
+	return snapshots, nil
+}
+
+func saveIndex(domain string, snapshots []Snapshot) error {
+	content, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexPath(domain), content, 0644)
+}
+
+func writeBlob(domain, hash, response string) error {
+	if err := os.MkdirAll(domainDir(domain), 0755); err != nil {
+		return err
+	}
+
+	path := blobPath(domain, hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(response)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func loadBlob(domain, hash string) (string, error) {
+	f, err := os.Open(blobPath(domain, hash))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
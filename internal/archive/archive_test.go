@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	base := "Domain Name: example.com\nRegistrar: Example\n>>> Last update of whois database: 2024-01-01T00:00:00Z <<<\n"
+	tests := []struct {
+		name  string
+		other string
+		same  bool
+	}{
+		{"更新时间不同", "Domain Name: example.com\nRegistrar: Example\n>>> Last update of WHOIS database: 2024-02-02T00:00:00Z <<<\n", true},
+		{"换行符不同", "Domain Name: example.com\r\nRegistrar: Example\r\n>>> Last update of whois database: 2024-01-01T00:00:00Z <<<\r\n", true},
+		{"查询时间不同", base + "Query time: 12 msec\n", true},
+		{"内容不同", "Domain Name: example.com\nRegistrar: Other\n", false},
+	}
+	for _, tt := range tests {
+		if got := contentHash(base) == contentHash(tt.other); got != tt.same {
+			t.Errorf("%s: 哈希相同 = %t", tt.name, got)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	a := "Registrar: A\nName Server: ns1.a.net\n"
+	b := "Registrar: B\nName Server: ns1.b.net\n"
+	api := `{"available": false}`
+
+	steps := []struct {
+		server   string
+		response string
+		previous string
+		changed  bool
+	}{
+		{"whois.example", a, "", false},
+		{"whois.example", a + ">>> Last update of whois database: now <<<\n", "", false}, // 相同内容只更新次数
+		{"whois.example", b, a, true},
+		{"registrar:nc", api, "", false}, // 切换来源不算变化
+		{"whois.example", b, "", false},  // 与同一服务器的上一份相同
+		{"whois.example", a, b, true},
+	}
+	for i, s := range steps {
+		previous, changed, err := Store("example.com", s.server, s.response, day(i+1), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if previous != s.previous || changed != s.changed {
+			t.Errorf("第 %d 步: previous %q changed %t，期望 %q %t", i+1, previous, changed, s.previous, s.changed)
+		}
+	}
+
+	snapshots, err := List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []string
+	for _, s := range snapshots {
+		servers = append(servers, s.Server)
+	}
+	if !reflect.DeepEqual(servers, []string{"whois.example", "whois.example", "registrar:nc", "whois.example", "whois.example"}) {
+		t.Errorf("存档服务器 %v", servers)
+	}
+	if snapshots[0].Count != 2 || !snapshots[0].LastSeen.Equal(day(2)) {
+		t.Errorf("去重后的第一份存档 %+v", snapshots[0])
+	}
+	if content, err := Load("example.com", snapshots[2].Hash); err != nil || content != api {
+		t.Errorf("Load = %q, %v", content, err)
+	}
+	if _, err := Load("example.com", "missing"); err == nil {
+		t.Error("不存在的存档应报错")
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	a, b, c := "Registrar: A\n", "Registrar: B\n", "Registrar: C\n"
+
+	for _, s := range []struct {
+		at       time.Time
+		response string
+	}{
+		{day(1), a},
+		{day(2), b},
+		{day(3), a},
+		{day(20), c},
+	} {
+		if _, _, err := Store("example.com", "", s.response, s.at, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Hash != contentHash(c) {
+		t.Fatalf("保留期外的存档未删除: %+v", snapshots)
+	}
+	for _, content := range []string{a, b} {
+		if _, err := os.Stat(blobPath("example.com", contentHash(content))); !os.IsNotExist(err) {
+			t.Errorf("过期内容的文件未删除: %v", err)
+		}
+	}
+
+	// 超过保留期仍保留最新的一份
+	if _, _, err := Store("example.com", "", c, day(60), 10); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots, _ = List("example.com"); len(snapshots) != 1 {
+		t.Errorf("最新的存档被删除: %+v", snapshots)
+	}
+}
+
+func TestPruneKeepsSharedBlobs(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	a := "Registrar: A\n"
+	if err := writeBlob("example.com", contentHash(a), a); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一内容既有过期的记录也有未过期的记录时保留文件
+	snapshots := []Snapshot{
+		{Hash: contentHash(a), LastSeen: now.AddDate(0, 0, -30)},
+		{Hash: "b", LastSeen: now.AddDate(0, 0, -20)},
+		{Hash: contentHash(a), LastSeen: now},
+	}
+	kept := prune("example.com", snapshots, now, 7)
+	if len(kept) != 1 {
+		t.Fatalf("kept = %+v", kept)
+	}
+	if _, err := os.Stat(blobPath("example.com", contentHash(a))); err != nil {
+		t.Errorf("仍被引用的文件被删除: %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []DiffLine
+	}{
+		{"相同", "a\nb\n", "a\r\nb", []DiffLine{{OpEqual, "a"}, {OpEqual, "b"}}},
+		{"修改一行", "a\nb\nc", "a\nx\nc", []DiffLine{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "x"}, {OpEqual, "c"}}},
+		{"追加", "a", "a\nb\nc", []DiffLine{{OpEqual, "a"}, {OpInsert, "b"}, {OpInsert, "c"}}},
+		{"删除", "a\nb\nc", "c", []DiffLine{{OpDelete, "a"}, {OpDelete, "b"}, {OpEqual, "c"}}},
+		{"保留最长公共子序列", "a\nb\nc\nd", "b\nc\ne\nd", []DiffLine{{OpDelete, "a"}, {OpEqual, "b"}, {OpEqual, "c"}, {OpInsert, "e"}, {OpEqual, "d"}}},
+	}
+	for _, tt := range tests {
+		if got := Diff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Diff = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
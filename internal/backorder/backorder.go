@@ -0,0 +1,299 @@
+package backorder
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/registrar"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 订单状态
+const (
+	StatusSucceeded = "succeeded"
+	StatusPending   = "pending" // 注册商已受理，结果需在注册商后台确认
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped" // 未下单：总开关关闭、超出预算或超过最高价格
+)
+
+// 同一次可注册期间下单失败后最多重试的次数
+const maxAttempts = 3
+
+// Order 是一次自动注册的记录
+type Order struct {
+	Domain    string    `json:"domain"`
+	Registrar string    `json:"registrar"`
+	Period    int       `json:"period"`
+	Status    string    `json:"status"`
+	Cost      float64   `json:"cost"` // 计入预算的金额：注册商返回的扣费，未返回时按报价或最高价格计
+	OrderID   string    `json:"order_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Quote 是查询时注册商给出的报价
+type Quote struct {
+	Price   string // 如 "12.98 USD"
+	Premium bool
+}
+
+// mu 保护订单记录和 inFlight，保证预算检查和扣减不会并发
+var mu sync.Mutex
+
+// inFlight 记录正在向注册商下单的域名及预留的预算
+var inFlight = make(map[string]float64)
+
+func ordersPath() string {
+	return config.GetConfigPath("orders.json")
+}
+
+// LoadOrders 读取所有订单记录
+func LoadOrders() ([]Order, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return loadOrders()
+}
+
+func loadOrders() ([]Order, error) {
+	content, err := os.ReadFile(ordersPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var orders []Order
+	if err := json.Unmarshal(content, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func saveOrders(orders []Order) error {
+	content, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ordersPath(), content, 0644)
+}
+
+// OrdersFor 返回域名的订单记录
+func OrdersFor(orders []Order, domain string) []Order {
+	var result []Order
+	for _, o := range orders {
+		if o.Domain == domain {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// Spent 返回已成功或已受理的订单累计花费
+func Spent(orders []Order) float64 {
+	var total float64
+	for _, o := range orders {
+		if o.Status == StatusSucceeded || o.Status == StatusPending {
+			total += o.Cost
+		}
+	}
+	return total
+}
+
+// Place 在域名确认可注册后下单。since 是本次可注册期间开始的时间，期间内已下单成功或失败次数
+// 达到上限时不再处理，返回 nil。因总开关或预算跳过的记录不阻止重试，打开总开关或提高预算后
+// 下一轮检查即会下单。总开关和预算每次从配置文件读取，修改后立即生效
+func Place(entry config.DomainEntry, quote Quote, since time.Time) (*Order, error) {
+	a := entry.AutoRegister
+	if a == nil || !a.Enabled {
+		return nil, nil
+	}
+
+	order, err := reserve(entry, quote, since)
+	if order == nil || err != nil || order.Status == StatusSkipped {
+		return order, err
+	}
+
+	// 下单期间不持有锁，一个注册商响应慢不会阻塞其他域名的订单
+	placeOrder(a, order)
+
+	mu.Lock()
+	defer mu.Unlock()
+	delete(inFlight, entry.Name)
+	return order, appendOrder(*order)
+}
+
+// reserve 检查去重、总开关、价格和预算。通过时为订单预留预算并返回待下单的订单；
+// 未通过时记录跳过的原因，原因与上次相同时返回 nil，避免每轮检查重复记录和通知
+func reserve(entry config.DomainEntry, quote Quote, since time.Time) (*Order, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := inFlight[entry.Name]; ok {
+		return nil, nil
+	}
+	orders, err := loadOrders()
+	if err != nil {
+		return nil, fmt.Errorf("读取订单记录失败: %w", err)
+	}
+
+	var last *Order
+	failures := 0
+	for i, o := range orders {
+		if o.Domain != entry.Name || o.At.Before(since) {
+			continue
+		}
+		switch o.Status {
+		case StatusSucceeded, StatusPending:
+			return nil, nil
+		case StatusFailed:
+			failures++
+		}
+		last = &orders[i]
+	}
+	if failures >= maxAttempts {
+		return nil, nil
+	}
+
+	a := entry.AutoRegister
+	period := a.Period
+	if period == 0 {
+		period = 1
+	}
+	order := Order{Domain: entry.Name, Registrar: a.Registrar, Period: period, At: time.Now()}
+
+	reason := checkLimits(a, quote, Spent(orders)+reserved(), &order)
+	if reason == "" {
+		inFlight[entry.Name] = order.Cost
+		return &order, nil
+	}
+	if last != nil && last.Status == StatusSkipped && last.Error == reason {
+		return nil, nil
+	}
+
+	order.Status = StatusSkipped
+	order.Error = reason
+	log.Printf("域名 %s 未自动注册: %s", entry.Name, reason)
+	return &order, appendOrder(order)
+}
+
+// reserved 返回正在下单的订单预留的预算；调用方需持有 mu
+func reserved() float64 {
+	var total float64
+	for _, cost := range inFlight {
+		total += cost
+	}
+	return total
+}
+
+// appendOrder 追加一条订单记录；调用方需持有 mu
+func appendOrder(order Order) error {
+	orders, err := loadOrders()
+	if err != nil {
+		return fmt.Errorf("读取订单记录失败: %w", err)
+	}
+	if err := saveOrders(append(orders, order)); err != nil {
+		return fmt.Errorf("保存订单记录失败: %w", err)
+	}
+	return nil
+}
+
+// checkLimits 检查总开关、最高价格和预算，通过时在 order 中填入预计花费；不通过时返回原因
+func checkLimits(a *config.AutoRegister, quote Quote, spent float64, order *Order) string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Sprintf("读取配置失败: %v", err)
+	}
+	if !cfg.AutoRegisterEnabled {
+		return "自动注册总开关已关闭"
+	}
+
+	// 没有报价时按最高价格预留预算，溢价域名必须有报价才能判断
+	price, ok := parsePrice(quote.Price)
+	switch {
+	case quote.Premium && !ok:
+		return "溢价域名且注册商没有报价"
+	case ok && price > a.MaxPrice:
+		return fmt.Sprintf("报价 %s 超过最高价格 %g", quote.Price, a.MaxPrice)
+	case !ok:
+		price = a.MaxPrice
+	}
+	cost := price * float64(order.Period)
+
+	if spent+cost > cfg.AutoRegisterBudget {
+		return fmt.Sprintf("预计花费 %g 将超出预算（已花费 %g，上限 %g）", cost, spent, cfg.AutoRegisterBudget)
+	}
+	order.Cost = cost
+	return ""
+}
+
+// placeOrder 通过注册商下单并记录结果
+func placeOrder(a *config.AutoRegister, order *Order) {
+	r, ok, err := config.FindRegistrar(a.Registrar)
+	if err == nil && !ok {
+		err = fmt.Errorf("未配置注册商 %s", a.Registrar)
+	}
+	var registerer registrar.Registerer
+	if err == nil {
+		registerer, err = registrar.NewRegisterer(r)
+	}
+
+	var result registrar.Order
+	if err == nil {
+		log.Printf("正在通过 %s 注册域名 %s（%d 年）", a.Registrar, order.Domain, order.Period)
+		result, err = registerer.Register(registrar.RegisterRequest{
+			Domain:  order.Domain,
+			Period:  order.Period,
+			Contact: a.Contact,
+		})
+	}
+	if err != nil {
+		order.Status = StatusFailed
+		order.Error = err.Error()
+		order.Cost = 0
+		log.Printf("域名 %s 自动注册失败: %v", order.Domain, err)
+		return
+	}
+
+	order.Status = StatusSucceeded
+	if result.Pending {
+		order.Status = StatusPending
+	}
+	order.OrderID = result.ID
+	if result.Price > 0 {
+		order.Cost = result.Price
+	}
+	log.Printf("域名 %s 自动注册%s，订单号 %s", order.Domain, order.StatusText(), order.OrderID)
+}
+
+var priceRe = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// parsePrice 读取报价中的金额，如 "12.98 USD"
+func parsePrice(s string) (float64, bool) {
+	m := priceRe.FindString(s)
+	if m == "" {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(m, 64)
+	return price, err == nil
+}
+
+// StatusText 返回订单状态的中文说明
+func (o Order) StatusText() string {
+	switch o.Status {
+	case StatusSucceeded:
+		return "成功"
+	case StatusPending:
+		return "已提交"
+	case StatusFailed:
+		return "失败"
+	case StatusSkipped:
+		return "未下单"
+	default:
+		return o.Status
+	}
+}
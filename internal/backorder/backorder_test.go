@@ -0,0 +1,198 @@
+package backorder
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setup 在临时目录中准备配置：mock 注册商、总开关和预算
+func setup(t *testing.T, enabled bool, budget float64) {
+	t.Helper()
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	writeFile(t, "registrars.yml", "registrars:\n  - name: test\n    type: mock\n")
+	setLimits(t, enabled, budget)
+}
+
+func setLimits(t *testing.T, enabled bool, budget float64) {
+	t.Helper()
+	writeFile(t, ".env", fmt.Sprintf("AUTO_REGISTER_ENABLED=%t\nAUTO_REGISTER_BUDGET=%g\n", enabled, budget))
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(config.GetConfigDir(), name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func entry(name string, maxPrice float64, period int) config.DomainEntry {
+	return config.DomainEntry{
+		Name:         name,
+		AutoRegister: &config.AutoRegister{Enabled: true, Registrar: "test", MaxPrice: maxPrice, Period: period},
+	}
+}
+
+func status(o *Order) string {
+	if o == nil {
+		return "nil"
+	}
+	return o.Status
+}
+
+func TestPlace(t *testing.T) {
+	since := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		enabled bool
+		budget  float64
+		entry   config.DomainEntry
+		quote   Quote
+		want    string
+		cost    float64
+	}{
+		{"总开关关闭", false, 100, entry("a.com", 10, 0), Quote{Price: "8 USD"}, StatusSkipped, 0},
+		{"按报价计入预算", true, 100, entry("a.com", 10, 2), Quote{Price: "8.5 USD"}, StatusSucceeded, 17},
+		{"没有报价时按最高价格计入预算", true, 100, entry("a.com", 10, 0), Quote{}, StatusSucceeded, 10},
+		{"报价超过最高价格", true, 100, entry("a.com", 10, 0), Quote{Price: "10.01 USD"}, StatusSkipped, 0},
+		{"溢价域名没有报价", true, 100, entry("a.com", 1000, 0), Quote{Premium: true}, StatusSkipped, 0},
+		{"溢价域名报价在最高价格内", true, 100, entry("a.com", 50, 0), Quote{Price: "45 USD", Premium: true}, StatusSucceeded, 45},
+		{"超出预算", true, 15, entry("a.com", 10, 2), Quote{Price: "8 USD"}, StatusSkipped, 0},
+		{"未开启自动注册", true, 100, config.DomainEntry{Name: "a.com"}, Quote{}, "nil", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t, tt.enabled, tt.budget)
+			order, err := Place(tt.entry, tt.quote, since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status(order) != tt.want {
+				t.Fatalf("状态 %s，期望 %s（%+v）", status(order), tt.want, order)
+			}
+			if order != nil && order.Cost != tt.cost {
+				t.Errorf("计入预算 %g，期望 %g", order.Cost, tt.cost)
+			}
+			if order != nil && order.Status == StatusSucceeded && order.OrderID == "" {
+				t.Error("mock 注册商应返回订单号")
+			}
+		})
+	}
+}
+
+func TestPlaceDedupe(t *testing.T) {
+	setup(t, true, 100)
+	e := entry("a.com", 10, 0)
+	first := time.Now().Add(-time.Hour)
+
+	if o, _ := Place(e, Quote{Price: "8"}, first); status(o) != StatusSucceeded {
+		t.Fatalf("首次下单 %s", status(o))
+	}
+	// 同一可注册期间不再下单
+	if o, _ := Place(e, Quote{Price: "8"}, first); o != nil {
+		t.Fatalf("重复下单 %+v", o)
+	}
+	// 新的可注册期间重新下单
+	if o, _ := Place(e, Quote{Price: "8"}, time.Now()); status(o) != StatusSucceeded {
+		t.Fatalf("新期间下单 %s", status(o))
+	}
+	// 其他域名不受影响
+	if o, _ := Place(entry("b.com", 10, 0), Quote{Price: "8"}, first); status(o) != StatusSucceeded {
+		t.Fatalf("其他域名下单 %s", status(o))
+	}
+
+	orders, err := LoadOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 3 || Spent(orders) != 24 {
+		t.Errorf("订单 %d 条，花费 %g", len(orders), Spent(orders))
+	}
+}
+
+func TestPlaceRetriesAfterSkip(t *testing.T) {
+	setup(t, false, 100)
+	e := entry("a.com", 10, 0)
+	since := time.Now().Add(-time.Hour)
+
+	if o, _ := Place(e, Quote{Price: "8"}, since); status(o) != StatusSkipped {
+		t.Fatalf("总开关关闭时 %s", status(o))
+	}
+	// 原因相同的跳过不重复记录
+	if o, _ := Place(e, Quote{Price: "8"}, since); o != nil {
+		t.Fatalf("重复记录跳过 %+v", o)
+	}
+
+	// 打开总开关后，同一可注册期间内可以下单，但预算不足
+	setLimits(t, true, 5)
+	if o, _ := Place(e, Quote{Price: "8"}, since); status(o) != StatusSkipped {
+		t.Fatalf("预算不足时 %s", status(o))
+	}
+
+	// 提高预算后下单
+	setLimits(t, true, 100)
+	if o, _ := Place(e, Quote{Price: "8"}, since); status(o) != StatusSucceeded {
+		t.Fatalf("提高预算后 %s", status(o))
+	}
+	if o, _ := Place(e, Quote{Price: "8"}, since); o != nil {
+		t.Fatalf("成功后重复下单 %+v", o)
+	}
+
+	orders, _ := LoadOrders()
+	var statuses []string
+	for _, o := range orders {
+		statuses = append(statuses, o.Status)
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint([]string{StatusSkipped, StatusSkipped, StatusSucceeded}) {
+		t.Errorf("订单记录 %v", statuses)
+	}
+}
+
+func TestPlaceFailuresLimited(t *testing.T) {
+	setup(t, true, 100)
+	// 未配置的注册商账户下单失败
+	e := config.DomainEntry{Name: "a.com", AutoRegister: &config.AutoRegister{Enabled: true, Registrar: "missing", MaxPrice: 10}}
+	since := time.Now().Add(-time.Hour)
+
+	for i := 0; i < maxAttempts; i++ {
+		if o, _ := Place(e, Quote{}, since); status(o) != StatusFailed || o.Cost != 0 {
+			t.Fatalf("第 %d 次下单 %+v", i+1, o)
+		}
+	}
+	if o, _ := Place(e, Quote{}, since); o != nil {
+		t.Fatalf("失败次数达到上限后仍下单 %+v", o)
+	}
+}
+
+func TestPlaceConcurrent(t *testing.T) {
+	setup(t, true, 25)
+	since := time.Now().Add(-time.Hour)
+
+	// 同一域名只下一单，预算只够两个域名
+	var wg sync.WaitGroup
+	for _, name := range []string{"a.com", "a.com", "b.com", "b.com", "c.com", "c.com"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			Place(entry(name, 10, 0), Quote{}, since)
+		}(name)
+	}
+	wg.Wait()
+
+	orders, _ := LoadOrders()
+	succeeded := 0
+	for _, o := range orders {
+		if o.Status == StatusSucceeded {
+			succeeded++
+		}
+	}
+	if succeeded != 2 || Spent(orders) != 20 {
+		t.Errorf("成功 %d 单，花费 %g: %+v", succeeded, Spent(orders), orders)
+	}
+}
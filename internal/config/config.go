@@ -10,29 +10,14 @@ import (
 	"sync"
 
 	"github.com/joho/godotenv"
-	"gopkg.in/yaml.v2"
 )
 
-var configDir string
-
-func init() {
-	// 检查环境变量中是否指定了配置目录
+// GetConfigDir 返回配置目录的路径，由环境变量 CONFIG_DIR 指定，未设置时使用 ./data
+func GetConfigDir() string {
 	if envConfigDir := os.Getenv("CONFIG_DIR"); envConfigDir != "" {
-		configDir = envConfigDir
-	} else {
-		// 如果环境变量未设置，则使用默认值
-		configDir = "./data"
+		return envConfigDir
 	}
-
-	// 确保配置目录存在
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		log.Fatalf("无法创建配置目录: %v", err)
-	}
-}
-
-// GetConfigDir 返回配置目录的路径
-func GetConfigDir() string {
-	return configDir
+	return "./data"
 }
 
 type Config struct {
@@ -41,11 +26,46 @@ type Config struct {
 	SMTPUsername          string `json:"SMTP_USERNAME"`
 	SMTPPassword          string `json:"SMTP_PASSWORD"`
 	RecipientEmail        string `json:"RECIPIENT_EMAIL"`
+	SMTPCc                string `json:"SMTP_CC"`
+	SMTPBcc               string `json:"SMTP_BCC"`
+	SMTPFromName          string `json:"SMTP_FROM_NAME"`
+	SMTPReplyTo           string `json:"SMTP_REPLY_TO"`
+	SMTPAuthMethod        string `json:"SMTP_AUTH_METHOD"` // PLAIN、LOGIN、CRAM-MD5
+	SMTPTLSMode           string `json:"SMTP_TLS_MODE"`    // none、starttls、tls，留空则按端口推断
 	WebPort               int    `json:"WEB_PORT"`
 	AuthUsername          string `json:"AUTH_USERNAME"`
 	AuthPassword          string `json:"AUTH_PASSWORD"`
 	SessionSecret         string `json:"SESSION_SECRET"`
 	QueryFrequencySeconds int    `json:"QUERY_FREQUENCY_SECONDS"`
+	DigestFrequency       string `json:"DIGEST_FREQUENCY"` // off、daily、weekly
+	DigestTime            string `json:"DIGEST_TIME"`      // HH:MM
+	DigestTimezone        string `json:"DIGEST_TIMEZONE"`
+	DigestWeekday         int    `json:"DIGEST_WEEKDAY"` // 0 为周日
+	DigestRecipients      string `json:"DIGEST_RECIPIENTS"`
+	EmailQuietHours       string `json:"EMAIL_QUIET_HOURS"` // HH:MM-HH:MM
+	EmailMaxPerHour       int    `json:"EMAIL_MAX_PER_HOUR"`
+	NotifyTimezone        string `json:"NOTIFY_TIMEZONE"`
+	NotifyDedupeMinutes   int    `json:"NOTIFY_DEDUPE_MINUTES"`
+	NotifyUrgentFinal     bool   `json:"NOTIFY_URGENT_FINAL"`    // 最终"可注册"通知不受免打扰限制
+	ExpiryReminderDays    string `json:"EXPIRY_REMINDER_DAYS"`   // 逗号分隔，如 60,30,7,1
+	ArchiveRetentionDays  int    `json:"ARCHIVE_RETENTION_DAYS"` // Whois 原始响应保留天数，0 为永久保留
+	NotifyFieldChanges    bool   `json:"NOTIFY_FIELD_CHANGES"`   // 注册商、DNS、状态或到期时间变化时通知
+	DNSPrefilter          bool   `json:"DNS_PREFILTER"`          // 先查询 DNS 委派，已委派的域名跳过部分 Whois 查询
+	DNSResolvers          string `json:"DNS_RESOLVERS"`          // 逗号分隔，留空使用系统设置
+	DNSWhoisEvery         int    `json:"DNS_WHOIS_EVERY"`        // 已委派的域名每隔多少轮仍执行一次 Whois 查询
+	ConfirmSources        string `json:"CONFIRM_SOURCES"`        // 确认可注册的来源，逗号分隔：whois、rdap、dns、secondary
+	ConfirmRequired       int    `json:"CONFIRM_REQUIRED"`       // 至少多少个来源判断为可注册才算确认
+	ConfirmConsecutive    int    `json:"CONFIRM_CONSECUTIVE"`    // 或者在时间窗口内连续多少次 Whois 判断为可注册，0 为关闭
+	ConfirmWindowMinutes  int    `json:"CONFIRM_WINDOW_MINUTES"`
+
+	// 自动注册
+	AutoRegisterEnabled bool    `json:"AUTO_REGISTER_ENABLED"` // 总开关，关闭时不会下任何订单
+	AutoRegisterBudget  float64 `json:"AUTO_REGISTER_BUDGET"`  // 累计花费上限
+
+	// 抢注轮询
+	DropCatchIntervalMs  int `json:"DROP_CATCH_INTERVAL_MS"`  // 每个域名两次查询的间隔（毫秒）
+	DropCatchRate        int `json:"DROP_CATCH_RATE"`         // 所有抢注轮询合计每秒最多查询次数
+	DropCatchWindowHours int `json:"DROP_CATCH_WINDOW_HOURS"` // 在预计删除时间前后多少小时内轮询
 }
 
 func ensureConfigFiles() error {
@@ -72,7 +92,7 @@ QUERY_FREQUENCY_SECONDS=300
 	}
 
 	for file, content := range files {
-		filePath := filepath.Join(configDir, file)
+		filePath := getConfigPath(file)
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
 			log.Printf("配置文件 %s 不存在，正在创建...", file)
 			err := os.WriteFile(filePath, []byte(content), 0644)
@@ -86,13 +106,14 @@ QUERY_FREQUENCY_SECONDS=300
 }
 
 func getConfigPath(filename string) string {
-	dir := "data"
+	dir := GetConfigDir()
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		log.Printf("创建配置目录: %s", dir)
-		os.Mkdir(dir, 0755)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Printf("无法创建配置目录: %v", err)
+		}
 	}
-	path := filepath.Join(dir, filename)
-	return path
+	return filepath.Join(dir, filename)
 }
 
 func LoadConfig() (*Config, error) {
@@ -126,135 +147,123 @@ func LoadConfig() (*Config, error) {
 		QueryFrequencySeconds = 300 // 默认为5分钟
 	}
 
-	config := &Config{
-		SMTPServer:            getEnv("SMTP_SERVER"),
-		SMTPPort:              smtpPort,
-		SMTPUsername:          getEnv("SMTP_USERNAME"),
-		SMTPPassword:          getEnv("SMTP_PASSWORD"),
-		RecipientEmail:        getEnv("RECIPIENT_EMAIL"),
-		WebPort:               webPort,
-		AuthUsername:          getEnv("AUTH_USERNAME"),
-		AuthPassword:          getEnv("AUTH_PASSWORD"),
-		SessionSecret:         getEnv("SESSION_SECRET"),
-		QueryFrequencySeconds: QueryFrequencySeconds,
-	}
-
-	// 清理 envMap 以释放内存
-	for k := range envMap {
-		delete(envMap, k)
-	}
-
-	return config, nil
-}
-
-func LoadDomainList() ([]string, error) {
-	file, err := os.ReadFile(getConfigPath("list.yml"))
+	digestWeekday, err := strconv.Atoi(getEnv("DIGEST_WEEKDAY"))
 	if err != nil {
-		return nil, err
+		digestWeekday = 1 // 默认为周一
 	}
 
-	var data struct {
-		Domains []string `yaml:"domains"`
-	}
+	emailMaxPerHour, _ := strconv.Atoi(getEnv("EMAIL_MAX_PER_HOUR"))
 
-	if err := yaml.Unmarshal(file, &data); err != nil {
-		return nil, err
+	notifyDedupeMinutes, err := strconv.Atoi(getEnv("NOTIFY_DEDUPE_MINUTES"))
+	if err != nil {
+		notifyDedupeMinutes = 60 // 默认 1 小时内不重复发送相同通知
 	}
 
-	return data.Domains, nil
-}
-
-func LoadWhoisServers() (map[string]string, error) {
-	file, err := os.ReadFile(getConfigPath("whois.yml"))
+	notifyUrgentFinal, err := strconv.ParseBool(getEnv("NOTIFY_URGENT_FINAL"))
 	if err != nil {
-		return nil, err
+		notifyUrgentFinal = true
 	}
 
-	var data struct {
-		WhoisServers map[string]string `yaml:"whois_servers"`
+	archiveRetentionDays, err := strconv.Atoi(getEnv("ARCHIVE_RETENTION_DAYS"))
+	if err != nil {
+		archiveRetentionDays = 90
 	}
 
-	if err := yaml.Unmarshal(file, &data); err != nil {
-		return nil, err
-	}
+	notifyFieldChanges, _ := strconv.ParseBool(getEnv("NOTIFY_FIELD_CHANGES"))
 
-	return data.WhoisServers, nil
-}
+	dnsPrefilter, _ := strconv.ParseBool(getEnv("DNS_PREFILTER"))
 
-func AddDomain(domain string) error {
-	domains, err := LoadDomainList()
-	if err != nil {
-		return err
+	dnsWhoisEvery, err := strconv.Atoi(getEnv("DNS_WHOIS_EVERY"))
+	if err != nil || dnsWhoisEvery < 1 {
+		dnsWhoisEvery = 10
 	}
 
-	domains = append(domains, domain)
-	return saveDomainList(domains)
-}
-
-func DeleteDomain(domain string) error {
-	domains, err := LoadDomainList()
-	if err != nil {
-		return err
+	confirmSources := getEnv("CONFIRM_SOURCES")
+	if confirmSources == "" {
+		confirmSources = "whois"
 	}
 
-	for i, d := range domains {
-		if d == domain {
-			domains = append(domains[:i], domains[i+1:]...)
-			break
-		}
+	confirmRequired, err := strconv.Atoi(getEnv("CONFIRM_REQUIRED"))
+	if err != nil || confirmRequired < 1 {
+		confirmRequired = 1
 	}
 
-	return saveDomainList(domains)
-}
+	confirmConsecutive, _ := strconv.Atoi(getEnv("CONFIRM_CONSECUTIVE"))
 
-func saveDomainList(domains []string) error {
-	data := struct {
-		Domains []string `yaml:"domains"`
-	}{
-		Domains: domains,
+	confirmWindowMinutes, err := strconv.Atoi(getEnv("CONFIRM_WINDOW_MINUTES"))
+	if err != nil || confirmWindowMinutes < 1 {
+		confirmWindowMinutes = 30
 	}
 
-	yamlData, err := yaml.Marshal(&data)
-	if err != nil {
-		return err
-	}
+	autoRegisterEnabled, _ := strconv.ParseBool(getEnv("AUTO_REGISTER_ENABLED"))
 
-	return os.WriteFile(getConfigPath("list.yml"), yamlData, 0644)
-}
+	autoRegisterBudget, _ := strconv.ParseFloat(getEnv("AUTO_REGISTER_BUDGET"), 64)
 
-func AddWhoisServer(tld, server string) error {
-	whoisServers, err := LoadWhoisServers()
-	if err != nil {
-		return err
+	dropCatchIntervalMs, err := strconv.Atoi(getEnv("DROP_CATCH_INTERVAL_MS"))
+	if err != nil || dropCatchIntervalMs < 100 {
+		dropCatchIntervalMs = 1000
 	}
 
-	whoisServers[tld] = server
-	return saveWhoisServers(whoisServers)
-}
-
-func DeleteWhoisServer(tld string) error {
-	whoisServers, err := LoadWhoisServers()
-	if err != nil {
-		return err
+	dropCatchRate, err := strconv.Atoi(getEnv("DROP_CATCH_RATE"))
+	if err != nil || dropCatchRate < 1 {
+		dropCatchRate = 5
 	}
 
-	delete(whoisServers, tld)
-	return saveWhoisServers(whoisServers)
-}
+	dropCatchWindowHours, err := strconv.Atoi(getEnv("DROP_CATCH_WINDOW_HOURS"))
+	if err != nil || dropCatchWindowHours < 1 {
+		dropCatchWindowHours = 6
+	}
 
-func saveWhoisServers(whoisServers map[string]string) error {
-	data := struct {
-		WhoisServers map[string]string `yaml:"whois_servers"`
-	}{
-		WhoisServers: whoisServers,
+	config := &Config{
+		SMTPServer:            getEnv("SMTP_SERVER"),
+		SMTPPort:              smtpPort,
+		SMTPUsername:          getEnv("SMTP_USERNAME"),
+		SMTPPassword:          getEnv("SMTP_PASSWORD"),
+		RecipientEmail:        getEnv("RECIPIENT_EMAIL"),
+		SMTPCc:                getEnv("SMTP_CC"),
+		SMTPBcc:               getEnv("SMTP_BCC"),
+		SMTPFromName:          getEnv("SMTP_FROM_NAME"),
+		SMTPReplyTo:           getEnv("SMTP_REPLY_TO"),
+		SMTPAuthMethod:        getEnv("SMTP_AUTH_METHOD"),
+		SMTPTLSMode:           getEnv("SMTP_TLS_MODE"),
+		WebPort:               webPort,
+		AuthUsername:          getEnv("AUTH_USERNAME"),
+		AuthPassword:          getEnv("AUTH_PASSWORD"),
+		SessionSecret:         getEnv("SESSION_SECRET"),
+		QueryFrequencySeconds: QueryFrequencySeconds,
+		DigestFrequency:       getEnv("DIGEST_FREQUENCY"),
+		DigestTime:            getEnv("DIGEST_TIME"),
+		DigestTimezone:        getEnv("DIGEST_TIMEZONE"),
+		DigestWeekday:         digestWeekday,
+		DigestRecipients:      getEnv("DIGEST_RECIPIENTS"),
+		EmailQuietHours:       getEnv("EMAIL_QUIET_HOURS"),
+		EmailMaxPerHour:       emailMaxPerHour,
+		NotifyTimezone:        getEnv("NOTIFY_TIMEZONE"),
+		NotifyDedupeMinutes:   notifyDedupeMinutes,
+		NotifyUrgentFinal:     notifyUrgentFinal,
+		ExpiryReminderDays:    getEnv("EXPIRY_REMINDER_DAYS"),
+		ArchiveRetentionDays:  archiveRetentionDays,
+		NotifyFieldChanges:    notifyFieldChanges,
+		DNSPrefilter:          dnsPrefilter,
+		DNSResolvers:          getEnv("DNS_RESOLVERS"),
+		DNSWhoisEvery:         dnsWhoisEvery,
+		ConfirmSources:        confirmSources,
+		ConfirmRequired:       confirmRequired,
+		ConfirmConsecutive:    confirmConsecutive,
+		ConfirmWindowMinutes:  confirmWindowMinutes,
+		AutoRegisterEnabled:   autoRegisterEnabled,
+		AutoRegisterBudget:    autoRegisterBudget,
+		DropCatchIntervalMs:   dropCatchIntervalMs,
+		DropCatchRate:         dropCatchRate,
+		DropCatchWindowHours:  dropCatchWindowHours,
 	}
 
-	yamlData, err := yaml.Marshal(&data)
-	if err != nil {
-		return err
+	// 清理 envMap 以释放内存
+	for k := range envMap {
+		delete(envMap, k)
 	}
 
-	return os.WriteFile(getConfigPath("whois.yml"), yamlData, 0644)
+	return config, nil
 }
 
 func UpdateRecipientEmail(email string) error {
@@ -300,11 +309,61 @@ SESSION_SECRET="your_random_secret_string"
   com: whois.verisign-grs.com
   net: whois.verisign-grs.com
   org: whois.pir.org
+`,
+		"rules.yml": `# 通知路由规则，按顺序匹配，所有匹配规则的路由都会生效
+# 示例：
+# rules:
+#   - name: 品牌域名
+#     match:
+#       tags: [brand]
+#       to: [可注册, 赎回期, 待删除]
+#     channels: [email]
+#     recipients: [brand@example.com]
+#   - name: 短 .com
+#     match:
+#       tlds: [com]
+#       max_length: 4
+#     recipients: [invest@example.com]
+#     stop: true
+# default:
+#   recipients: []
+rules: []
+`,
+		"registrars.yml": `# 注册商 API 账户，可在 whois.yml 中作为查询服务器（registrar: 名称），
+# 或在确认来源中以 registrar:名称 引用
+# 示例：
+# registrars:
+#   - name: nc
+#     type: namecheap
+#     username: your_user
+#     api_key: your_key
+#     client_ip: 1.2.3.4
+#   - name: gd
+#     type: godaddy
+#     api_key: your_key
+#     api_secret: your_secret
+#   - name: dd
+#     type: dynadot
+#     api_key: your_key
+#   - name: ali
+#     type: aliyun
+#     api_key: your_access_key_id
+#     api_secret: your_access_key_secret
+#   - name: registry
+#     type: epp
+#     address: epp.example.net:700
+#     username: your_clid
+#     password: your_password
+#     cert_file: data/epp.crt
+#     key_file: data/epp.key
+#   - name: drill
+#     type: mock
+registrars: []
 `,
 	}
 
 	for file, content := range files {
-		filePath := filepath.Join(configDir, file)
+		filePath := getConfigPath(file)
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
 			log.Printf("配置文件 %s 不存在，正在创建...", file)
 			err := os.WriteFile(filePath, []byte(content), 0644)
@@ -319,7 +378,7 @@ SESSION_SECRET="your_random_secret_string"
 
 // 将 getConfigPath 改为公开函数
 func GetConfigPath(filename string) string {
-	return filepath.Join(configDir, filename)
+	return getConfigPath(filename)
 }
 
 func SaveConfig(cfg *Config) error {
@@ -347,6 +406,54 @@ func SaveConfig(cfg *Config) error {
 	updateEnv("SMTP_USERNAME", cfg.SMTPUsername)
 	updateEnv("SMTP_PASSWORD", cfg.SMTPPassword)
 	updateEnv("RECIPIENT_EMAIL", cfg.RecipientEmail)
+	updateEnv("SMTP_FROM_NAME", cfg.SMTPFromName)
+	// 认证方式和加密方式留空表示自动，允许清空
+	env["SMTP_AUTH_METHOD"] = cfg.SMTPAuthMethod
+	env["SMTP_TLS_MODE"] = cfg.SMTPTLSMode
+
+	// 抄送、密送和回复地址允许清空
+	env["SMTP_CC"] = cfg.SMTPCc
+	env["SMTP_BCC"] = cfg.SMTPBcc
+	env["SMTP_REPLY_TO"] = cfg.SMTPReplyTo
+
+	updateEnv("DIGEST_FREQUENCY", cfg.DigestFrequency)
+	updateEnv("DIGEST_TIME", cfg.DigestTime)
+	updateEnv("DIGEST_TIMEZONE", cfg.DigestTimezone)
+	env["DIGEST_WEEKDAY"] = strconv.Itoa(cfg.DigestWeekday)
+	env["DIGEST_RECIPIENTS"] = cfg.DigestRecipients
+
+	updateEnv("NOTIFY_TIMEZONE", cfg.NotifyTimezone)
+	updateEnv("EXPIRY_REMINDER_DAYS", cfg.ExpiryReminderDays)
+	env["EMAIL_QUIET_HOURS"] = cfg.EmailQuietHours
+	env["EMAIL_MAX_PER_HOUR"] = strconv.Itoa(cfg.EmailMaxPerHour)
+	env["NOTIFY_DEDUPE_MINUTES"] = strconv.Itoa(cfg.NotifyDedupeMinutes)
+	env["NOTIFY_URGENT_FINAL"] = strconv.FormatBool(cfg.NotifyUrgentFinal)
+	env["NOTIFY_FIELD_CHANGES"] = strconv.FormatBool(cfg.NotifyFieldChanges)
+	env["ARCHIVE_RETENTION_DAYS"] = strconv.Itoa(cfg.ArchiveRetentionDays)
+	env["DNS_PREFILTER"] = strconv.FormatBool(cfg.DNSPrefilter)
+	env["DNS_RESOLVERS"] = cfg.DNSResolvers
+	if cfg.DNSWhoisEvery != 0 {
+		env["DNS_WHOIS_EVERY"] = strconv.Itoa(cfg.DNSWhoisEvery)
+	}
+	updateEnv("CONFIRM_SOURCES", cfg.ConfirmSources)
+	if cfg.ConfirmRequired != 0 {
+		env["CONFIRM_REQUIRED"] = strconv.Itoa(cfg.ConfirmRequired)
+	}
+	env["CONFIRM_CONSECUTIVE"] = strconv.Itoa(cfg.ConfirmConsecutive)
+	if cfg.ConfirmWindowMinutes != 0 {
+		env["CONFIRM_WINDOW_MINUTES"] = strconv.Itoa(cfg.ConfirmWindowMinutes)
+	}
+	env["AUTO_REGISTER_ENABLED"] = strconv.FormatBool(cfg.AutoRegisterEnabled)
+	env["AUTO_REGISTER_BUDGET"] = strconv.FormatFloat(cfg.AutoRegisterBudget, 'f', -1, 64)
+	if cfg.DropCatchIntervalMs != 0 {
+		env["DROP_CATCH_INTERVAL_MS"] = strconv.Itoa(cfg.DropCatchIntervalMs)
+	}
+	if cfg.DropCatchRate != 0 {
+		env["DROP_CATCH_RATE"] = strconv.Itoa(cfg.DropCatchRate)
+	}
+	if cfg.DropCatchWindowHours != 0 {
+		env["DROP_CATCH_WINDOW_HOURS"] = strconv.Itoa(cfg.DropCatchWindowHours)
+	}
 	updateEnv("AUTH_USERNAME", cfg.AuthUsername)
 	updateEnv("AUTH_PASSWORD", cfg.AuthPassword)
 	updateEnv("SESSION_SECRET", cfg.SessionSecret)
@@ -362,6 +469,12 @@ func SaveConfig(cfg *Config) error {
 		env["QUERY_FREQUENCY_SECONDS"] = strconv.Itoa(cfg.QueryFrequencySeconds)
 	}
 
+	// 确认来源无效或所需数量超过来源数量时，可注册状态永远无法确认，拒绝保存
+	confirmRequired, _ := strconv.Atoi(env["CONFIRM_REQUIRED"])
+	if err := validateConfirm(env["CONFIRM_SOURCES"], confirmRequired); err != nil {
+		return err
+	}
+
 	if err := godotenv.Write(env, envPath); err != nil {
 		log.Printf("写入 .env 文件时出错: %v", err)
 		return fmt.Errorf("error writing .env file: %w", err)
@@ -396,15 +509,18 @@ func ReloadConfig() error {
 
 var globalConfig *Config
 
-func init() {
-	var err error
-	globalConfig, err = LoadConfig()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+// GetConfig 返回当前配置，首次调用时从配置目录加载
+func GetConfig() *Config {
+	configMutex.RLock()
+	cfg := globalConfig
+	configMutex.RUnlock()
+	if cfg != nil {
+		return cfg
 	}
-}
 
-func GetConfig() *Config {
+	if err := ReloadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 	configMutex.RLock()
 	defer configMutex.RUnlock()
 	return globalConfig
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func TestSaveConfigResetsSMTPModes(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+
+	cfg := &Config{SMTPServer: "smtp.example.com", SMTPTLSMode: "tls", SMTPAuthMethod: "LOGIN"}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// 清空表示恢复自动选择
+	cfg.SMTPTLSMode, cfg.SMTPAuthMethod = "", ""
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	env, err := godotenv.Read(getConfigPath(".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["SMTP_TLS_MODE"] != "" || env["SMTP_AUTH_METHOD"] != "" || env["SMTP_SERVER"] != "smtp.example.com" {
+		t.Errorf(".env = %v", env)
+	}
+}
+
+func TestValidateConfirm(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	if err := os.WriteFile(getConfigPath("registrars.yml"), []byte("registrars:\n  - name: nc\n    type: mock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec     string
+		required int
+		sources  []string
+		err      bool
+	}{
+		{"", 1, []string{"whois"}, false},
+		{"whois, RDAP,dns", 3, []string{"whois", "rdap", "dns"}, false},
+		{"rdap,rdap,secondary", 4, []string{"whois", "rdap", "secondary"}, true},
+		{"rdpa", 1, []string{"whois"}, true},
+		{"Registrar:nc", 2, []string{"whois", "registrar:nc"}, false},
+		{"registrar:missing", 1, []string{"whois", "registrar:missing"}, true},
+		{"registrar:", 1, []string{"whois"}, true},
+	}
+	for _, tt := range tests {
+		sources, _ := ParseConfirmSources(tt.spec)
+		if !reflect.DeepEqual(sources, tt.sources) {
+			t.Errorf("ParseConfirmSources(%q) = %v，期望 %v", tt.spec, sources, tt.sources)
+		}
+		if err := validateConfirm(tt.spec, tt.required); (err != nil) != tt.err {
+			t.Errorf("validateConfirm(%q, %d) = %v", tt.spec, tt.required, err)
+		}
+	}
+
+	// 保存设置时拒绝无法满足的确认要求
+	if err := SaveConfig(&Config{ConfirmSources: "rdap", ConfirmRequired: 3}); err == nil {
+		t.Error("所需数量超过来源数量时应拒绝保存")
+	}
+}
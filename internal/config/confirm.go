@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 确认可注册的来源，注册商 API 写作 registrar:名称
+var confirmSourceNames = []string{"whois", "rdap", "dns", "secondary"}
+
+// confirmRegistrarPrefix 是注册商 API 来源的前缀
+const confirmRegistrarPrefix = "registrar:"
+
+// ParseConfirmSources 解析确认来源配置，主 Whois 查询总是排在第一位。
+// 返回所有有效的来源，存在未知的来源时同时返回错误
+func ParseConfirmSources(spec string) ([]string, error) {
+	sources := []string{"whois"}
+	var unknown []string
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		// 注册商名称区分大小写
+		if strings.HasPrefix(strings.ToLower(s), confirmRegistrarPrefix) {
+			if len(s) == len(confirmRegistrarPrefix) {
+				unknown = append(unknown, s)
+				continue
+			}
+			s = confirmRegistrarPrefix + s[len(confirmRegistrarPrefix):]
+		} else {
+			s = strings.ToLower(s)
+			if !containsState(confirmSourceNames, s) {
+				unknown = append(unknown, s)
+				continue
+			}
+		}
+		if !containsState(sources, s) {
+			sources = append(sources, s)
+		}
+	}
+	if len(unknown) > 0 {
+		return sources, fmt.Errorf("未知的确认来源: %s", strings.Join(unknown, ", "))
+	}
+	return sources, nil
+}
+
+// validateConfirm 检查确认来源和所需来源数量，避免可注册状态永远无法确认
+func validateConfirm(spec string, required int) error {
+	sources, err := ParseConfirmSources(spec)
+	if err != nil {
+		return err
+	}
+	for _, s := range sources {
+		if !strings.HasPrefix(s, confirmRegistrarPrefix) {
+			continue
+		}
+		name := s[len(confirmRegistrarPrefix):]
+		if _, ok, err := FindRegistrar(name); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("确认来源 %s 引用的注册商账户 %s 不存在", s, name)
+		}
+	}
+	if required > len(sources) {
+		return fmt.Errorf("至少需要 %d 个来源确认，但只配置了 %d 个来源（%s）", required, len(sources), strings.Join(sources, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"Puff/internal/domainname"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 域名监控模式
+const (
+	ModeWatch   = "watch"   // 关注域名是否可注册
+	ModeOwned   = "owned"   // 自有域名，关注到期续费
+	ModeChanges = "changes" // 已注册的域名，关注注册商、DNS、状态和到期时间的变化
+)
+
+// DomainEntry 是 list.yml 中的一条域名记录。
+// 为兼容旧版本，记录既可以是纯字符串，也可以是包含以下字段的对象。
+type DomainEntry struct {
+	Name          string         `yaml:"name" json:"name"`                   // U-label 形式
+	Punycode      string         `yaml:"punycode,omitempty" json:"punycode"` // 国际化域名的 A-label 形式
+	Tags          []string       `yaml:"tags,omitempty" json:"tags"`
+	Note          string         `yaml:"note,omitempty" json:"note"`
+	Owner         string         `yaml:"owner,omitempty" json:"owner"`
+	Mode          string         `yaml:"mode,omitempty" json:"mode"`
+	Priority      int            `yaml:"priority,omitempty" json:"priority"`
+	CheckInterval int            `yaml:"check_interval,omitempty" json:"check_interval"` // 秒，0 表示使用全局查询频率
+	WhoisServer   string         `yaml:"whois_server,omitempty" json:"whois_server"`
+	Channels      []string       `yaml:"channels,omitempty" json:"channels"` // 为空表示发送到所有渠道
+	Expected      *ExpectedState `yaml:"expected,omitempty" json:"expected"`
+	AutoRegister  *AutoRegister  `yaml:"auto_register,omitempty" json:"auto_register"`
+	DropCatch     bool           `yaml:"drop_catch,omitempty" json:"drop_catch"`     // 在预计删除时间窗口内高频轮询
+	AlertStates   []string       `yaml:"alert_states,omitempty" json:"alert_states"` // 这些受限状态也视为可注册并发送通知
+}
+
+// 受限状态：未注册但不能正常注册，默认不发送可注册通知
+var restrictionStates = []string{"reserved", "premium", "blocked", "invalid"}
+
+// AutoRegister 是域名确认可注册后自动下单的设置
+type AutoRegister struct {
+	Enabled   bool    `yaml:"enabled" json:"enabled"`
+	Registrar string  `yaml:"registrar" json:"registrar"`           // registrars.yml 中的账户名称
+	Contact   string  `yaml:"contact,omitempty" json:"contact"`     // 注册商或注册局的联系人 ID，为空时使用账户默认联系人
+	Period    int     `yaml:"period,omitempty" json:"period"`       // 注册年限，0 表示 1 年
+	MaxPrice  float64 `yaml:"max_price,omitempty" json:"max_price"` // 单次注册的最高价格，与注册商报价同一币种
+}
+
+// ExpectedState 是自有域名的预期 Whois 状态，实际记录偏离时会发出安全告警
+type ExpectedState struct {
+	Registrar   string   `yaml:"registrar,omitempty" json:"registrar"`       // 不区分大小写，实际注册商包含该值即视为一致
+	NameServers []string `yaml:"name_servers,omitempty" json:"name_servers"` // 必须与实际的域名服务器集合完全一致
+	Locks       []string `yaml:"locks,omitempty" json:"locks"`               // 必须存在的 EPP 状态，如 clientTransferProhibited
+}
+
+// IsEmpty 判断是否没有设置任何预期
+func (s *ExpectedState) IsEmpty() bool {
+	return s == nil || (s.Registrar == "" && len(s.NameServers) == 0 && len(s.Locks) == 0)
+}
+
+func (e *DomainEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*e = DomainEntry{Name: name}
+		return nil
+	}
+
+	type plain DomainEntry
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*e = DomainEntry(p)
+	return nil
+}
+
+func (e DomainEntry) MarshalYAML() (interface{}, error) {
+	// 没有附加信息的记录仍然写成纯字符串
+	if e.isPlain() {
+		return e.Name, nil
+	}
+
+	type plain DomainEntry
+	p := plain(e)
+	if p.Mode == ModeWatch {
+		p.Mode = ""
+	}
+	return p, nil
+}
+
+func (e DomainEntry) isPlain() bool {
+	return e.Punycode == "" && len(e.Tags) == 0 && e.Note == "" && e.Owner == "" &&
+		(e.Mode == "" || e.Mode == ModeWatch) && e.Priority == 0 &&
+		e.CheckInterval == 0 && e.WhoisServer == "" && len(e.Channels) == 0 && e.Expected == nil &&
+		e.AutoRegister == nil && !e.DropCatch && len(e.AlertStates) == 0
+}
+
+// IsOwned 判断域名是否为自有域名
+func (e DomainEntry) IsOwned() bool {
+	return e.Mode == ModeOwned
+}
+
+// DetectsChanges 判断域名是否处于变更监测模式
+func (e DomainEntry) DetectsChanges() bool {
+	return e.Mode == ModeChanges
+}
+
+// WantsRestriction 判断处于受限状态（如 premium）的域名是否仍需要可注册通知
+func (e DomainEntry) WantsRestriction(restriction string) bool {
+	return containsState(e.AlertStates, restriction)
+}
+
+// HasTag 判断域名是否带有指定标签（不区分大小写）
+func (e DomainEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize 将域名规范化为可注册域名，清理记录中的空白字段并补全默认值
+func (e *DomainEntry) Normalize() error {
+	if e.Name = strings.TrimSpace(e.Name); e.Name != "" {
+		name, err := domainname.Normalize(e.Name)
+		if err != nil {
+			return err
+		}
+		e.Name = name
+	}
+	e.Punycode = ""
+	if domainname.IsIDN(e.Name) {
+		e.Punycode = domainname.ToASCII(e.Name)
+	}
+	e.Note = strings.TrimSpace(e.Note)
+	e.Owner = strings.TrimSpace(e.Owner)
+	e.WhoisServer = strings.TrimSpace(e.WhoisServer)
+	e.Tags = cleanList(e.Tags)
+	e.Channels = cleanList(e.Channels)
+	e.AlertStates = cleanList(e.AlertStates)
+	for i, state := range e.AlertStates {
+		e.AlertStates[i] = strings.ToLower(state)
+		if !containsState(restrictionStates, e.AlertStates[i]) {
+			return fmt.Errorf("未知的受限状态: %s", state)
+		}
+	}
+
+	if e.Expected != nil {
+		e.Expected.Registrar = strings.TrimSpace(e.Expected.Registrar)
+		e.Expected.Locks = cleanList(e.Expected.Locks)
+		nameServers := cleanList(e.Expected.NameServers)
+		for i, ns := range nameServers {
+			nameServers[i] = strings.TrimSuffix(strings.ToLower(ns), ".")
+		}
+		e.Expected.NameServers = nameServers
+		if e.Expected.IsEmpty() {
+			e.Expected = nil
+		}
+	}
+
+	if a := e.AutoRegister; a != nil {
+		a.Registrar = strings.TrimSpace(a.Registrar)
+		a.Contact = strings.TrimSpace(a.Contact)
+		switch {
+		case !a.Enabled && a.Registrar == "" && a.Contact == "" && a.Period == 0 && a.MaxPrice == 0:
+			e.AutoRegister = nil
+		case a.Enabled && a.Registrar == "":
+			return fmt.Errorf("自动注册需要指定注册商账户")
+		case a.Enabled && a.MaxPrice <= 0:
+			return fmt.Errorf("自动注册需要设置最高价格")
+		case a.Period < 0 || a.Period > 10:
+			return fmt.Errorf("注册年限必须在 1 到 10 年之间")
+		case a.MaxPrice < 0:
+			return fmt.Errorf("最高价格不能为负数")
+		}
+	}
+
+	switch e.Mode {
+	case "":
+		e.Mode = ModeWatch
+	case ModeWatch, ModeOwned, ModeChanges:
+	default:
+		return fmt.Errorf("未知的监控模式: %s", e.Mode)
+	}
+
+	if e.CheckInterval < 0 {
+		return fmt.Errorf("检查间隔不能为负数")
+	}
+	return nil
+}
+
+func containsState(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanList(list []string) []string {
+	var cleaned []string
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+// domainListFile 是 list.yml 的结构
+type domainListFile struct {
+	Domains []DomainEntry `yaml:"domains"`
+	// Owned 是旧版本保存自有域名的字段，读取时合并到 Domains 的 mode 中
+	Owned []string `yaml:"owned,omitempty"`
+	// invalid 是无法识别的记录，不参与监控，保存时原样写回
+	invalid []DomainEntry
+}
+
+func loadDomainListFile() (*domainListFile, error) {
+	file, err := os.ReadFile(getConfigPath("list.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var data domainListFile
+	if err := yaml.Unmarshal(file, &data); err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]bool)
+	for _, d := range data.Owned {
+		if name, err := domainname.Normalize(d); err == nil {
+			owned[name] = true
+		}
+	}
+	data.Owned = nil
+
+	// 手动编辑的记录同样规范化，规范化后重复的记录只保留第一条
+	seen := make(map[string]bool, len(data.Domains))
+	entries := data.Domains[:0]
+	for _, e := range data.Domains {
+		input := e
+		if err := e.Normalize(); err != nil {
+			log.Printf("list.yml 中的域名 %s 配置错误，已跳过: %v", input.Name, err)
+			data.invalid = append(data.invalid, input)
+			continue
+		}
+		if e.Name == "" {
+			continue
+		}
+		if seen[e.Name] {
+			log.Printf("list.yml 中的 %s 与已有的 %s 重复，已忽略", input.Name, e.Name)
+			continue
+		}
+		seen[e.Name] = true
+		if owned[e.Name] {
+			e.Mode = ModeOwned
+		}
+		entries = append(entries, e)
+	}
+	data.Domains = entries
+
+	return &data, nil
+}
+
+func saveDomainListFile(data *domainListFile) error {
+	out := *data
+	out.Domains = append(append([]DomainEntry{}, data.Domains...), data.invalid...)
+	yamlData, err := yaml.Marshal(&out)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(getConfigPath("list.yml"), yamlData, 0644)
+}
+
+// LoadDomainEntries 返回 list.yml 中的全部域名记录
+func LoadDomainEntries() ([]DomainEntry, error) {
+	data, err := loadDomainListFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Domains, nil
+}
+
+// LoadDomainList 返回 list.yml 中的域名名称
+func LoadDomainList() ([]string, error) {
+	entries, err := LoadDomainEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(entries))
+	for _, e := range entries {
+		domains = append(domains, e.Name)
+	}
+	return domains, nil
+}
+
+// LoadOwnedDomains 返回标记为自有的域名
+func LoadOwnedDomains() ([]string, error) {
+	entries, err := LoadDomainEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []string
+	for _, e := range entries {
+		if e.IsOwned() {
+			owned = append(owned, e.Name)
+		}
+	}
+	return owned, nil
+}
+
+// GetDomainEntry 返回指定域名的记录
+func GetDomainEntry(domain string) (DomainEntry, bool, error) {
+	entries, err := LoadDomainEntries()
+	if err != nil {
+		return DomainEntry{}, false, err
+	}
+
+	for _, e := range entries {
+		if e.Name == domain {
+			return e, true, nil
+		}
+	}
+	return DomainEntry{}, false, nil
+}
+
+func AddDomain(domain string) error {
+	_, err := AddDomainEntry(DomainEntry{Name: domain})
+	return err
+}
+
+// AddDomainEntry 添加一条域名记录，域名会被规范化为可注册域名，重复的域名会被拒绝
+func AddDomainEntry(entry DomainEntry) (DomainEntry, error) {
+	name, err := domainname.Normalize(entry.Name)
+	if err != nil {
+		return entry, err
+	}
+	entry.Name = name
+	if err := entry.Normalize(); err != nil {
+		return entry, err
+	}
+
+	data, err := loadDomainListFile()
+	if err != nil {
+		return entry, err
+	}
+
+	for _, e := range data.Domains {
+		if e.Name == entry.Name {
+			return entry, fmt.Errorf("域名 %s 已存在", entry.Name)
+		}
+	}
+
+	data.Domains = append(data.Domains, entry)
+	return entry, saveDomainListFile(data)
+}
+
+// AddDomainEntries 批量添加域名记录，只写入一次文件
+func AddDomainEntries(entries []DomainEntry) error {
+	data, err := loadDomainListFile()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(data.Domains)+len(entries))
+	for _, e := range data.Domains {
+		seen[e.Name] = true
+	}
+
+	for _, entry := range entries {
+		name, err := domainname.Normalize(entry.Name)
+		if err != nil {
+			return err
+		}
+		entry.Name = name
+		if err := entry.Normalize(); err != nil {
+			return fmt.Errorf("域名 %s 配置错误: %w", entry.Name, err)
+		}
+		if seen[name] {
+			return fmt.Errorf("域名 %s 已存在", name)
+		}
+		seen[name] = true
+		data.Domains = append(data.Domains, entry)
+	}
+	return saveDomainListFile(data)
+}
+
+// UpdateDomainEntry 替换同名的域名记录
+func UpdateDomainEntry(entry DomainEntry) error {
+	if err := entry.Normalize(); err != nil {
+		return err
+	}
+
+	data, err := loadDomainListFile()
+	if err != nil {
+		return err
+	}
+
+	for i, e := range data.Domains {
+		if e.Name == entry.Name {
+			data.Domains[i] = entry
+			return saveDomainListFile(data)
+		}
+	}
+	return fmt.Errorf("域名 %s 不存在", entry.Name)
+}
+
+// SetDomainOwned 设置域名是否为自有域名
+func SetDomainOwned(domain string, owned bool) error {
+	entry, exists, err := GetDomainEntry(domain)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("域名 %s 不存在", domain)
+	}
+
+	if owned {
+		entry.Mode = ModeOwned
+	} else if entry.Mode == ModeOwned {
+		// 只取消自有状态，不影响变化检测等其他模式
+		entry.Mode = ModeWatch
+	}
+	return UpdateDomainEntry(entry)
+}
+
+func DeleteDomain(domain string) error {
+	data, err := loadDomainListFile()
+	if err != nil {
+		return err
+	}
+
+	for i, e := range data.Domains {
+		if e.Name == domain {
+			data.Domains = append(data.Domains[:i], data.Domains[i+1:]...)
+			break
+		}
+	}
+
+	return saveDomainListFile(data)
+}
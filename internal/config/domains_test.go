@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDomainEntryNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{"WWW.Example.COM.", "example.com", false},
+		{" https://blog.example.co.uk/path ", "example.co.uk", false},
+		{"xn--fsqu00a.xn--fiqs8s", "例子.中国", false},
+		{"", "", false},
+		{"not a domain", "", true},
+	}
+
+	for _, tt := range tests {
+		e := DomainEntry{Name: tt.input}
+		err := e.Normalize()
+		if (err != nil) != tt.err {
+			t.Errorf("Normalize(%q) err = %v", tt.input, err)
+			continue
+		}
+		if !tt.err && e.Name != tt.want {
+			t.Errorf("Normalize(%q) = %q，期望 %q", tt.input, e.Name, tt.want)
+		}
+	}
+}
+
+func TestLoadDomainEntriesNormalizes(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+
+	content := `domains:
+  - example.com
+  - WWW.Example.COM.
+  - name: Shop.Example.NET
+    note: 商店
+  - ""
+owned:
+  - SHOP.example.net
+`
+	if err := os.WriteFile(getConfigPath("list.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadDomainEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"example.com", "example.net"}) {
+		t.Fatalf("域名 %v", names)
+	}
+	if entries[1].Mode != ModeOwned || entries[1].Note != "商店" {
+		t.Errorf("example.net: %+v", entries[1])
+	}
+
+	// 更新时使用未规范化的名称同样能找到记录
+	if err := UpdateDomainEntry(DomainEntry{Name: "Example.COM", Note: "已更新"}); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok, err := GetDomainEntry("example.com")
+	if err != nil || !ok || entry.Note != "已更新" {
+		t.Errorf("更新后 %+v %t %v", entry, ok, err)
+	}
+}
+
+func TestSetDomainOwnedKeepsOtherModes(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+
+	content := `domains:
+  - name: a.com
+    mode: changes
+  - name: b.com
+`
+	if err := os.WriteFile(getConfigPath("list.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		domain string
+		owned  bool
+		want   string
+	}{
+		{"a.com", false, ModeChanges},
+		{"b.com", true, ModeOwned},
+		{"b.com", false, ModeWatch},
+		{"a.com", true, ModeOwned},
+	}
+	for _, tt := range tests {
+		if err := SetDomainOwned(tt.domain, tt.owned); err != nil {
+			t.Fatal(err)
+		}
+		entry, _, err := GetDomainEntry(tt.domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := entry.Mode; mode != tt.want && !(mode == "" && tt.want == ModeWatch) {
+			t.Errorf("SetDomainOwned(%s, %t) 后模式为 %q，期望 %q", tt.domain, tt.owned, mode, tt.want)
+		}
+	}
+}
+
+func TestLoadDomainEntriesSkipsInvalid(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+
+	content := `domains:
+  - example.com
+  - not a domain
+  - name: example.net
+    mode: bogus
+  - example.org
+`
+	if err := os.WriteFile(getConfigPath("list.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadDomainEntries()
+	if err != nil {
+		t.Fatalf("无效记录不应导致加载失败: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"example.com", "example.org"}) {
+		t.Fatalf("域名 %v", names)
+	}
+
+	// 保存其他记录时保留无效记录，便于手动修正
+	if err := UpdateDomainEntry(DomainEntry{Name: "example.org", Note: "已更新"}); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(getConfigPath("list.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"not a domain", "bogus", "已更新"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("保存后缺少 %q:\n%s", want, saved)
+		}
+	}
+}
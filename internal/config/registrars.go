@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 支持的注册商 API
+const (
+	RegistrarNamecheap = "namecheap"
+	RegistrarGoDaddy   = "godaddy"
+	RegistrarDynadot   = "dynadot"
+	RegistrarAliyun    = "aliyun"
+	RegistrarEPP       = "epp"  // 通过代理商的 EPP 账户直接查询注册局
+	RegistrarMock      = "mock" // 不调用任何接口，用于演练自动注册流程
+)
+
+// Registrar 是 registrars.yml 中的一个注册商 API 账户
+type Registrar struct {
+	Name      string `yaml:"name" json:"name"` // 在 whois.yml 和确认来源中引用的名称
+	Type      string `yaml:"type" json:"type"`
+	BaseURL   string `yaml:"base_url,omitempty" json:"base_url"` // 为空时使用注册商的正式环境地址
+	Username  string `yaml:"username,omitempty" json:"username"`
+	APIKey    string `yaml:"api_key,omitempty" json:"api_key"`
+	APISecret string `yaml:"api_secret,omitempty" json:"api_secret"`
+	ClientIP  string `yaml:"client_ip,omitempty" json:"client_ip"` // Namecheap 要求的白名单 IP
+
+	// EPP 账户
+	Address            string `yaml:"address,omitempty" json:"address"` // EPP 服务器的 host:port
+	Password           string `yaml:"password,omitempty" json:"password"`
+	CertFile           string `yaml:"cert_file,omitempty" json:"cert_file"` // 客户端证书和私钥，注册局要求时填写
+	KeyFile            string `yaml:"key_file,omitempty" json:"key_file"`
+	CAFile             string `yaml:"ca_file,omitempty" json:"ca_file"` // 服务器使用自签名证书时的 CA 证书
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify"`
+}
+
+// RegistrarsFile 是 registrars.yml 的结构
+type RegistrarsFile struct {
+	Registrars []Registrar `yaml:"registrars" json:"registrars"`
+}
+
+// Validate 检查注册商名称是否唯一以及各类型必需的凭据
+func (f *RegistrarsFile) Validate() error {
+	seen := make(map[string]bool)
+	for i, r := range f.Registrars {
+		if r.Name == "" {
+			return fmt.Errorf("第 %d 个注册商没有名称", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("注册商名称 %s 重复", r.Name)
+		}
+		seen[r.Name] = true
+
+		var missing bool
+		switch r.Type {
+		case RegistrarNamecheap:
+			missing = r.Username == "" || r.APIKey == "" || r.ClientIP == ""
+		case RegistrarGoDaddy, RegistrarAliyun:
+			missing = r.APIKey == "" || r.APISecret == ""
+		case RegistrarDynadot:
+			missing = r.APIKey == ""
+		case RegistrarEPP:
+			missing = r.Address == "" || r.Username == "" || r.Password == ""
+		case RegistrarMock:
+		default:
+			return fmt.Errorf("注册商 %s 的类型 %s 不受支持", r.Name, r.Type)
+		}
+		if missing {
+			return fmt.Errorf("注册商 %s 缺少 %s 所需的凭据", r.Name, r.Type)
+		}
+	}
+	return nil
+}
+
+// ParseRegistrars 解析 registrars.yml 的内容
+func ParseRegistrars(content []byte) (*RegistrarsFile, error) {
+	var data RegistrarsFile
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// LoadRegistrars 读取注册商配置，文件不存在时返回空列表
+func LoadRegistrars() ([]Registrar, error) {
+	content, err := os.ReadFile(GetConfigPath("registrars.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	data, err := ParseRegistrars(content)
+	if err != nil {
+		return nil, err
+	}
+	return data.Registrars, nil
+}
+
+// FindRegistrar 按名称查找注册商
+func FindRegistrar(name string) (Registrar, bool, error) {
+	registrars, err := LoadRegistrars()
+	if err != nil {
+		return Registrar{}, false, err
+	}
+	for _, r := range registrars {
+		if r.Name == name {
+			return r, true, nil
+		}
+	}
+	return Registrar{}, false, nil
+}
+
+// LoadRegistrarsRaw 返回 registrars.yml 的原始内容
+func LoadRegistrarsRaw() (string, error) {
+	content, err := os.ReadFile(GetConfigPath("registrars.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(content), nil
+}
+
+// SaveRegistrarsRaw 校验并保存 registrars.yml
+func SaveRegistrarsRaw(content string) error {
+	if _, err := ParseRegistrars([]byte(content)); err != nil {
+		return err
+	}
+	return os.WriteFile(GetConfigPath("registrars.yml"), []byte(content), 0600)
+}
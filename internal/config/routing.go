@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RuleMatch 描述路由规则的匹配条件，未设置的条件视为匹配
+type RuleMatch struct {
+	Tags      []string `yaml:"tags,omitempty" json:"tags"` // 任一标签匹配即可
+	TLDs      []string `yaml:"tlds,omitempty" json:"tlds"`
+	MinLength int      `yaml:"min_length,omitempty" json:"min_length"` // 不含后缀的名称长度
+	MaxLength int      `yaml:"max_length,omitempty" json:"max_length"`
+	Pattern   string   `yaml:"pattern,omitempty" json:"pattern"` // 匹配完整域名的正则表达式
+	From      []string `yaml:"from,omitempty" json:"from"`       // 变化前的状态
+	To        []string `yaml:"to,omitempty" json:"to"`           // 变化后的状态
+}
+
+// Route 描述通知的去向
+type Route struct {
+	Channels   []string `yaml:"channels,omitempty" json:"channels"`     // 为空表示所有渠道
+	Recipients []string `yaml:"recipients,omitempty" json:"recipients"` // 为空表示使用默认收件人
+}
+
+// RoutingRule 是一条通知路由规则
+type RoutingRule struct {
+	Name  string    `yaml:"name" json:"name"`
+	Match RuleMatch `yaml:"match" json:"match"`
+	Route `yaml:",inline"`
+	// Stop 为 true 时，匹配此规则后不再继续匹配后续规则
+	Stop bool `yaml:"stop,omitempty" json:"stop"`
+}
+
+// RoutingRules 是 rules.yml 的结构
+type RoutingRules struct {
+	Rules []RoutingRule `yaml:"rules" json:"rules"`
+	// Default 在没有任何规则匹配时使用
+	Default Route `yaml:"default" json:"default"`
+}
+
+// Validate 检查规则中的正则表达式等配置是否有效
+func (r *RoutingRules) Validate() error {
+	for i, rule := range r.Rules {
+		if rule.Match.Pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(rule.Match.Pattern); err != nil {
+			return fmt.Errorf("第 %d 条规则 %s 的正则表达式无效: %w", i+1, rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// ParseRoutingRules 解析 rules.yml 的内容
+func ParseRoutingRules(content []byte) (*RoutingRules, error) {
+	var rules RoutingRules
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return nil, err
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// LoadRoutingRules 读取通知路由规则，文件不存在时返回空规则
+func LoadRoutingRules() (*RoutingRules, error) {
+	content, err := os.ReadFile(GetConfigPath("rules.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RoutingRules{}, nil
+		}
+		return nil, err
+	}
+	return ParseRoutingRules(content)
+}
+
+// LoadRoutingRulesRaw 返回 rules.yml 的原始内容
+func LoadRoutingRulesRaw() (string, error) {
+	content, err := os.ReadFile(GetConfigPath("rules.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(content), nil
+}
+
+// SaveRoutingRulesRaw 校验并保存 rules.yml
+func SaveRoutingRulesRaw(content string) error {
+	if _, err := ParseRoutingRules([]byte(content)); err != nil {
+		return err
+	}
+	return os.WriteFile(GetConfigPath("rules.yml"), []byte(content), 0644)
+}
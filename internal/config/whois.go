@@ -0,0 +1,435 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"gopkg.in/yaml.v2"
+)
+
+// WhoisServer 是 whois.yml 中的一个查询服务器。
+// 为兼容旧版本，既可以写成纯字符串（主机名），也可以写成包含以下字段的对象。
+type WhoisServer struct {
+	Host    string `yaml:"host,omitempty" json:"host"`       // 主机名，或以 http:// / https:// 开头的 RDAP 服务地址
+	Port    int    `yaml:"port,omitempty" json:"port"`       // 0 表示默认的 43 端口
+	Query   string `yaml:"query,omitempty" json:"query"`     // 查询模板，{domain} 替换为 A-label，{unicode} 替换为 U-label
+	Charset string `yaml:"charset,omitempty" json:"charset"` // 响应的字符编码，为空表示自动识别
+	// Registrar 不为空时使用 registrars.yml 中该名称的注册商 API 查询，此时忽略其他字段
+	Registrar string `yaml:"registrar,omitempty" json:"registrar,omitempty"`
+	// HTTP 不为空时通过网页或 JSON 接口查询，用于没有可用 Whois 服务的后缀，此时忽略 Host、Port 和 Query
+	HTTP *HTTPCheck `yaml:"http,omitempty" json:"http,omitempty"`
+}
+
+// HTTP 查询规则映射的域名状态
+const (
+	HTTPStatusAvailable     = "available"
+	HTTPStatusRegistered    = "registered"
+	HTTPStatusRedemption    = "redemption"
+	HTTPStatusPendingDelete = "pendingDelete"
+)
+
+// HTTPCheck 是通过 HTTP 请求查询域名状态的配置，URL 和请求体中的 {domain} 替换为 A-label，{unicode} 替换为 U-label
+type HTTPCheck struct {
+	URL     string            `yaml:"url" json:"url"`
+	Method  string            `yaml:"method,omitempty" json:"method"` // 默认 GET
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers"`
+	Body    string            `yaml:"body,omitempty" json:"body"`
+	Rules   []HTTPRule        `yaml:"rules" json:"rules"` // 按顺序匹配，第一条匹配的规则决定域名状态
+}
+
+// HTTPRule 将响应映射为域名状态，规则中设置的条件需全部满足
+type HTTPRule struct {
+	Status     string `yaml:"status" json:"status"`                     // available、registered、redemption 或 pendingDelete
+	StatusCode int    `yaml:"status_code,omitempty" json:"status_code"` // 响应的 HTTP 状态码
+	JSONPath   string `yaml:"jsonpath,omitempty" json:"jsonpath"`       // 如 $.data.available 或 $.results[0].status
+	Equals     string `yaml:"equals,omitempty" json:"equals"`           // JSONPath 的取值（不区分大小写），为空表示取值存在且不为空、false 或 0
+	Regex      string `yaml:"regex,omitempty" json:"regex"`             // 对响应正文匹配的正则表达式
+
+	pattern *regexp.Regexp // 加载配置时由 Regex 编译
+}
+
+// Pattern 返回加载配置时编译的正则表达式，未设置 Regex 或配置未经检查时返回 nil
+func (r HTTPRule) Pattern() *regexp.Regexp {
+	return r.pattern
+}
+
+// normalize 检查 HTTP 查询配置
+func (h *HTTPCheck) normalize() error {
+	h.URL = strings.TrimSpace(h.URL)
+	h.Method = strings.ToUpper(strings.TrimSpace(h.Method))
+	if h.Method == "" {
+		h.Method = http.MethodGet
+	}
+
+	if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+		return fmt.Errorf("无效的 HTTP 查询地址: %s", h.URL)
+	}
+	if len(h.Rules) == 0 {
+		return fmt.Errorf("HTTP 查询 %s 没有配置规则", h.URL)
+	}
+
+	for i := range h.Rules {
+		rule := &h.Rules[i]
+		switch rule.Status {
+		case HTTPStatusAvailable, HTTPStatusRegistered, HTTPStatusRedemption, HTTPStatusPendingDelete:
+		default:
+			return fmt.Errorf("未知的 HTTP 查询状态: %s", rule.Status)
+		}
+		if rule.StatusCode == 0 && rule.JSONPath == "" && rule.Regex == "" {
+			return fmt.Errorf("HTTP 查询规则 %s 没有设置匹配条件", rule.Status)
+		}
+		if rule.JSONPath != "" && !strings.HasPrefix(rule.JSONPath, "$") {
+			return fmt.Errorf("JSONPath 必须以 $ 开头: %s", rule.JSONPath)
+		}
+		rule.pattern = nil
+		if rule.Regex != "" {
+			pattern, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("无效的正则表达式 %s: %w", rule.Regex, err)
+			}
+			rule.pattern = pattern
+		}
+	}
+	return nil
+}
+
+// IsRDAP 判断服务器是否为 RDAP 服务
+func (s WhoisServer) IsRDAP() bool {
+	return strings.HasPrefix(s.Host, "http://") || strings.HasPrefix(s.Host, "https://")
+}
+
+// Address 返回服务器的地址，用于区分服务器、记录日志和健康统计
+func (s WhoisServer) Address() string {
+	if s.Registrar != "" {
+		return "registrar:" + s.Registrar
+	}
+	if s.HTTP != nil {
+		return s.HTTP.URL
+	}
+	if s.IsRDAP() || s.Port == 0 || s.Port == 43 {
+		return s.Host
+	}
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// Normalize 清理服务器配置中的空白字段并检查端口
+func (s *WhoisServer) Normalize() error {
+	s.Host = strings.TrimSpace(s.Host)
+	if !s.IsRDAP() {
+		s.Host = strings.ToLower(s.Host)
+	}
+	s.Query = strings.TrimSpace(s.Query)
+	s.Charset = strings.ToLower(strings.TrimSpace(s.Charset))
+
+	s.Registrar = strings.TrimSpace(s.Registrar)
+	if s.Registrar != "" {
+		return nil
+	}
+	if s.HTTP != nil {
+		return s.HTTP.normalize()
+	}
+	if s.Host == "" {
+		return fmt.Errorf("服务器地址不能为空")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("无效的端口: %d", s.Port)
+	}
+	if s.Charset != "" {
+		if _, err := htmlindex.Get(s.Charset); err != nil {
+			return fmt.Errorf("不支持的字符编码: %s", s.Charset)
+		}
+	}
+	return nil
+}
+
+func (s *WhoisServer) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var host string
+	if err := unmarshal(&host); err == nil {
+		*s = WhoisServer{Host: host}
+		return nil
+	}
+
+	type plain WhoisServer
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*s = WhoisServer(p)
+	return nil
+}
+
+func (s WhoisServer) MarshalYAML() (interface{}, error) {
+	// 没有附加信息的服务器仍然写成纯字符串
+	if (s.Port == 0 || s.Port == 43) && s.Query == "" && s.Charset == "" && s.HTTP == nil && s.Registrar == "" {
+		return s.Host, nil
+	}
+
+	type plain WhoisServer
+	return plain(s), nil
+}
+
+// ServerList 是一个后缀按优先顺序排列的服务器，可以是单个服务器或列表
+type ServerList []WhoisServer
+
+func (l *ServerList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single WhoisServer
+	if err := unmarshal(&single); err == nil && (single.Host != "" || single.HTTP != nil || single.Registrar != "") {
+		*l = ServerList{single}
+		return nil
+	}
+
+	var list []WhoisServer
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+func (l ServerList) MarshalYAML() (interface{}, error) {
+	// 只有一个服务器时保持旧版本的写法
+	if len(l) == 1 {
+		return l[0].MarshalYAML()
+	}
+	return []WhoisServer(l), nil
+}
+
+// indexOf 按地址查找服务器
+func (l ServerList) indexOf(address string) int {
+	for i, s := range l {
+		if strings.EqualFold(s.Address(), address) {
+			return i
+		}
+	}
+	return -1
+}
+
+// 源地址的选择方式
+const (
+	SelectionRoundRobin = "round-robin" // 轮流使用
+	SelectionLRU        = "lru"         // 使用最久未用于该服务器的地址
+)
+
+// WhoisNetwork 是 Whois 和 RDAP 查询的出站网络设置
+type WhoisNetwork struct {
+	Proxy           string   `yaml:"proxy,omitempty" json:"proxy"`                       // socks5://、socks5h:// 或 http:// 代理，可包含用户名和密码
+	SourceAddresses []string `yaml:"source_addresses,omitempty" json:"source_addresses"` // 本地源地址池（IPv4/IPv6），为空表示由系统选择
+	Selection       string   `yaml:"selection,omitempty" json:"selection"`               // 源地址的选择方式，默认轮流使用
+}
+
+// Normalize 清理出站网络设置并检查代理地址和源地址
+func (n *WhoisNetwork) Normalize() error {
+	n.Proxy = strings.TrimSpace(n.Proxy)
+	n.SourceAddresses = cleanList(n.SourceAddresses)
+	n.Selection = strings.ToLower(strings.TrimSpace(n.Selection))
+
+	if n.Proxy != "" {
+		u, err := url.Parse(n.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("无效的代理地址: %s", n.Proxy)
+		}
+		switch u.Scheme {
+		case "socks5", "socks5h", "http":
+		default:
+			return fmt.Errorf("不支持的代理类型: %s", u.Scheme)
+		}
+	}
+
+	for _, addr := range n.SourceAddresses {
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("无效的源地址: %s", addr)
+		}
+	}
+
+	switch n.Selection {
+	case "":
+		n.Selection = SelectionRoundRobin
+	case SelectionRoundRobin, SelectionLRU:
+	default:
+		return fmt.Errorf("未知的源地址选择方式: %s", n.Selection)
+	}
+	return nil
+}
+
+// StatusRules 是识别保留、溢价、屏蔽和无效域名的响应短语，不区分大小写，
+// 只匹配状态字段和响应开头的提示行，补充内置规则。whois.yml 中按 TLD 配置，default 适用于所有后缀
+type StatusRules struct {
+	Reserved []string `yaml:"reserved,omitempty"`
+	Premium  []string `yaml:"premium,omitempty"`
+	Blocked  []string `yaml:"blocked,omitempty"`
+	Invalid  []string `yaml:"invalid,omitempty"`
+}
+
+// whoisFile 是 whois.yml 的结构
+type whoisFile struct {
+	WhoisServers map[string]ServerList `yaml:"whois_servers"`
+	// SecondaryServers 是用于交叉确认的备用 Whois 服务器
+	SecondaryServers map[string]ServerList  `yaml:"secondary_servers,omitempty"`
+	Network          WhoisNetwork           `yaml:"network,omitempty"`
+	StatusRules      map[string]StatusRules `yaml:"status_rules,omitempty"`
+}
+
+func loadWhoisFile() (*whoisFile, error) {
+	file, err := os.ReadFile(getConfigPath("whois.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var data whoisFile
+	if err := yaml.Unmarshal(file, &data); err != nil {
+		return nil, err
+	}
+	if data.WhoisServers == nil {
+		data.WhoisServers = make(map[string]ServerList)
+	}
+	for _, servers := range []map[string]ServerList{data.WhoisServers, data.SecondaryServers} {
+		for tld, list := range servers {
+			for i := range list {
+				if err := list[i].Normalize(); err != nil {
+					return nil, fmt.Errorf("%s 的服务器配置错误: %w", tld, err)
+				}
+			}
+		}
+	}
+	return &data, nil
+}
+
+func LoadWhoisServers() (map[string]ServerList, error) {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return nil, err
+	}
+	return data.WhoisServers, nil
+}
+
+// LoadSecondaryWhoisServers 返回 whois.yml 中 secondary_servers 配置的备用服务器
+func LoadSecondaryWhoisServers() (map[string]ServerList, error) {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return nil, err
+	}
+	return data.SecondaryServers, nil
+}
+
+// LoadStatusRules 返回 whois.yml 中按 TLD 配置的受限状态识别规则
+func LoadStatusRules() (map[string]StatusRules, error) {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return nil, err
+	}
+	return data.StatusRules, nil
+}
+
+// LoadWhoisNetwork 返回 whois.yml 中的出站网络设置
+func LoadWhoisNetwork() (WhoisNetwork, error) {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return WhoisNetwork{}, err
+	}
+	if err := data.Network.Normalize(); err != nil {
+		return WhoisNetwork{}, err
+	}
+	return data.Network, nil
+}
+
+// SaveWhoisNetwork 保存出站网络设置
+func SaveWhoisNetwork(network WhoisNetwork) error {
+	if err := network.Normalize(); err != nil {
+		return err
+	}
+
+	data, err := loadWhoisFile()
+	if err != nil {
+		return err
+	}
+
+	// 默认的选择方式不写入文件
+	if network.Selection == SelectionRoundRobin {
+		network.Selection = ""
+	}
+	data.Network = network
+	return saveWhoisFile(data)
+}
+
+// AddWhoisServer 将服务器追加到后缀的服务器列表末尾
+func AddWhoisServer(tld string, server WhoisServer) error {
+	if err := server.Normalize(); err != nil {
+		return err
+	}
+
+	data, err := loadWhoisFile()
+	if err != nil {
+		return err
+	}
+
+	if data.WhoisServers[tld].indexOf(server.Address()) >= 0 {
+		return fmt.Errorf("%s 已包含服务器 %s", tld, server.Address())
+	}
+	data.WhoisServers[tld] = append(data.WhoisServers[tld], server)
+	return saveWhoisFile(data)
+}
+
+// SetWhoisServerOrder 按给定的地址顺序重新排列后缀的服务器，列表必须包含且只包含已有的服务器
+func SetWhoisServerOrder(tld string, order []string) error {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return err
+	}
+
+	current, ok := data.WhoisServers[tld]
+	if !ok {
+		return fmt.Errorf("未配置 %s 的 Whois 服务器", tld)
+	}
+	if len(order) != len(current) {
+		return fmt.Errorf("服务器列表与 %s 的配置不一致", tld)
+	}
+
+	reordered := make(ServerList, 0, len(order))
+	for _, server := range order {
+		i := current.indexOf(server)
+		if i < 0 || reordered.indexOf(server) >= 0 {
+			return fmt.Errorf("服务器列表与 %s 的配置不一致", tld)
+		}
+		reordered = append(reordered, current[i])
+	}
+	data.WhoisServers[tld] = reordered
+	return saveWhoisFile(data)
+}
+
+// DeleteWhoisServer 删除后缀中指定地址的服务器，address 为空时删除整个后缀
+func DeleteWhoisServer(tld, address string) error {
+	data, err := loadWhoisFile()
+	if err != nil {
+		return err
+	}
+
+	if address != "" {
+		list := data.WhoisServers[tld]
+		if i := list.indexOf(address); i >= 0 {
+			list = append(list[:i], list[i+1:]...)
+		}
+		if len(list) > 0 {
+			data.WhoisServers[tld] = list
+			return saveWhoisFile(data)
+		}
+	}
+
+	delete(data.WhoisServers, tld)
+	return saveWhoisFile(data)
+}
+
+func saveWhoisFile(data *whoisFile) error {
+	yamlData, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(getConfigPath("whois.yml"), yamlData, 0644)
+}
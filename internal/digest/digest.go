@@ -0,0 +1,274 @@
+package digest
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/monitor"
+	"Puff/internal/notifier"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 到期提醒的分段（天）
+var expiryBuckets = []int{30, 60, 90}
+
+// Report 汇总一个周期内的域名情况
+type Report struct {
+	Start    time.Time
+	End      time.Time
+	Changes  []monitor.StateChange
+	Dropping []monitor.DomainStatus
+	// Expiring 按天数分段列出即将到期的自有域名
+	Expiring    map[int][]monitor.DomainStatus
+	ErrorCounts map[string]int
+	TotalErrors int
+}
+
+// BuildReport 根据当前监控数据生成 [start, end] 周期内的摘要
+func BuildReport(start, end time.Time) *Report {
+	report := &Report{
+		Start:       start,
+		End:         end,
+		Changes:     monitor.GetStateChanges(start),
+		Expiring:    make(map[int][]monitor.DomainStatus),
+		ErrorCounts: make(map[string]int),
+	}
+
+	for _, status := range monitor.GetDomainStatuses() {
+		if status.LastChecked.IsZero() || !status.Registered {
+			continue
+		}
+
+		if status.Redemption || status.PendingDelete {
+			report.Dropping = append(report.Dropping, status)
+			continue
+		}
+
+		if !status.Owned || status.ExpirationDate.IsZero() || status.ExpirationDate.Before(end) {
+			continue
+		}
+		days := int(status.ExpirationDate.Sub(end).Hours() / 24)
+		for _, bucket := range expiryBuckets {
+			if days <= bucket {
+				report.Expiring[bucket] = append(report.Expiring[bucket], status)
+				break
+			}
+		}
+	}
+
+	for _, e := range monitor.GetCheckErrors(start) {
+		report.ErrorCounts[e.Domain]++
+		report.TotalErrors++
+	}
+
+	sort.Slice(report.Dropping, func(i, j int) bool {
+		return report.Dropping[i].PredictedDropDate.Before(report.Dropping[j].PredictedDropDate)
+	})
+	for _, list := range report.Expiring {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].ExpirationDate.Before(list[j].ExpirationDate)
+		})
+	}
+
+	return report
+}
+
+// Message 将摘要转换为通知内容
+func (r *Report) Message(loc *time.Location) notifier.Message {
+	const layout = "2006-01-02 15:04"
+	var text, body strings.Builder
+
+	period := fmt.Sprintf("%s 至 %s", r.Start.In(loc).Format(layout), r.End.In(loc).Format(layout))
+	text.WriteString("统计周期：" + period + "\r\n\r\n")
+	body.WriteString("<p>统计周期：" + period + "</p>")
+
+	text.WriteString(fmt.Sprintf("【状态变化】共 %d 条\r\n", len(r.Changes)))
+	body.WriteString(fmt.Sprintf("<h3>状态变化（%d）</h3>", len(r.Changes)))
+	if len(r.Changes) > 0 {
+		body.WriteString("<table><tr><th>域名</th><th>变化</th><th>时间</th></tr>")
+		for _, c := range r.Changes {
+			text.WriteString(fmt.Sprintf("- %s: %s -> %s (%s)\r\n", c.Domain, c.From, c.To, c.At.In(loc).Format(layout)))
+			body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s → %s</td><td>%s</td></tr>",
+				html.EscapeString(c.Domain), c.From, c.To, c.At.In(loc).Format(layout)))
+		}
+		body.WriteString("</table>")
+	}
+
+	text.WriteString(fmt.Sprintf("\r\n【赎回期/待删除】共 %d 个\r\n", len(r.Dropping)))
+	body.WriteString(fmt.Sprintf("<h3>赎回期 / 待删除（%d）</h3>", len(r.Dropping)))
+	if len(r.Dropping) > 0 {
+		body.WriteString("<table><tr><th>域名</th><th>状态</th><th>预计释放</th></tr>")
+		for _, s := range r.Dropping {
+			status := monitor.GetDomainStatusString(&s)
+			drop := s.PredictedDropDate.In(loc).Format("2006-01-02")
+			text.WriteString(fmt.Sprintf("- %s: %s，预计 %s 释放\r\n", s.Domain, status, drop))
+			body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>", html.EscapeString(s.Domain), status, drop))
+		}
+		body.WriteString("</table>")
+	}
+
+	for _, bucket := range expiryBuckets {
+		list := r.Expiring[bucket]
+		text.WriteString(fmt.Sprintf("\r\n【%d 天内到期】共 %d 个\r\n", bucket, len(list)))
+		body.WriteString(fmt.Sprintf("<h3>%d 天内到期（%d）</h3>", bucket, len(list)))
+		if len(list) == 0 {
+			continue
+		}
+		body.WriteString("<table><tr><th>域名</th><th>到期时间</th></tr>")
+		for _, s := range list {
+			expiry := s.ExpirationDate.In(loc).Format("2006-01-02")
+			text.WriteString(fmt.Sprintf("- %s: %s\r\n", s.Domain, expiry))
+			body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td></tr>", html.EscapeString(s.Domain), expiry))
+		}
+		body.WriteString("</table>")
+	}
+
+	text.WriteString(fmt.Sprintf("\r\n【检查错误】共 %d 次\r\n", r.TotalErrors))
+	body.WriteString(fmt.Sprintf("<h3>检查错误（%d）</h3>", r.TotalErrors))
+	if r.TotalErrors > 0 {
+		domains := make([]string, 0, len(r.ErrorCounts))
+		for d := range r.ErrorCounts {
+			domains = append(domains, d)
+		}
+		sort.Strings(domains)
+
+		body.WriteString("<table><tr><th>域名</th><th>次数</th></tr>")
+		for _, d := range domains {
+			text.WriteString(fmt.Sprintf("- %s: %d\r\n", d, r.ErrorCounts[d]))
+			body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td></tr>", html.EscapeString(d), r.ErrorCounts[d]))
+		}
+		body.WriteString("</table>")
+	}
+
+	return notifier.Message{
+		Subject: "域名监控摘要",
+		Text:    text.String(),
+		HTML:    notifier.RenderEmailHTML("域名监控摘要", body.String()),
+	}
+}
+
+// location 返回摘要使用的时区，未配置或无效时使用本地时区
+func location(cfg *config.Config) *time.Location {
+	if cfg.DigestTimezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(cfg.DigestTimezone)
+	if err != nil {
+		log.Printf("无效的摘要时区 %s: %v", cfg.DigestTimezone, err)
+		return time.Local
+	}
+	return loc
+}
+
+// lastScheduled 返回不晚于 now 的最近一次计划发送时间及周期长度
+func lastScheduled(now time.Time, cfg *config.Config) (time.Time, time.Duration, bool) {
+	var period time.Duration
+	switch strings.ToLower(cfg.DigestFrequency) {
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	default:
+		return time.Time{}, 0, false
+	}
+
+	hour, minute := 9, 0
+	if cfg.DigestTime != "" {
+		t, err := time.Parse("15:04", cfg.DigestTime)
+		if err != nil {
+			log.Printf("无效的摘要发送时间 %s: %v", cfg.DigestTime, err)
+		} else {
+			hour, minute = t.Hour(), t.Minute()
+		}
+	}
+
+	local := now.In(location(cfg))
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, local.Location())
+	if scheduled.After(local) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+	if period > 24*time.Hour {
+		for int(scheduled.Weekday()) != cfg.DigestWeekday%7 {
+			scheduled = scheduled.AddDate(0, 0, -1)
+		}
+	}
+
+	return scheduled, period, true
+}
+
+// Send 生成并发送 [start, end] 周期的摘要
+func Send(start, end time.Time, cfg *config.Config) error {
+	msg := BuildReport(start, end).Message(location(cfg))
+	msg.Recipients = notifier.ParseAddressList(cfg.DigestRecipients)
+	return notifier.Broadcast(msg, cfg)
+}
+
+// 上次发送摘要的时间，保存在文件中，重启后不会跳过当期摘要
+func lastRunPath() string {
+	return config.GetConfigPath("digest_last_run")
+}
+
+// loadLastRun 读取上次发送时间，从未发送过时返回当前时间，避免首次启动就补发
+func loadLastRun() time.Time {
+	content, err := os.ReadFile(lastRunPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取摘要发送记录失败: %v", err)
+		}
+		return saveLastRun(time.Now())
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(content)))
+	if err != nil {
+		log.Printf("摘要发送记录无效: %v", err)
+		return saveLastRun(time.Now())
+	}
+	return t
+}
+
+func saveLastRun(t time.Time) time.Time {
+	if err := os.WriteFile(lastRunPath(), []byte(t.Format(time.RFC3339)), 0644); err != nil {
+		log.Printf("保存摘要发送记录失败: %v", err)
+	}
+	return t
+}
+
+// runDue 在到达计划时间且当期尚未发送时发送摘要，返回新的上次发送时间。
+// 发送成功后才保存记录，失败时在下一分钟重试
+func runDue(now, lastRun time.Time, cfg *config.Config, send func(start, end time.Time, cfg *config.Config) error) time.Time {
+	scheduled, period, ok := lastScheduled(now, cfg)
+	if !ok || !scheduled.After(lastRun) {
+		return lastRun
+	}
+
+	log.Printf("开始发送域名监控摘要")
+	if err := send(scheduled.Add(-period), scheduled, cfg); err != nil {
+		log.Printf("发送摘要失败，将在下一分钟重试: %v", err)
+		return lastRun
+	}
+	return saveLastRun(now)
+}
+
+// StartScheduler 启动摘要定时任务，每分钟检查一次是否到达发送时间
+func StartScheduler() {
+	go func() {
+		lastRun := loadLastRun()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				log.Printf("加载配置失败: %v", err)
+				continue
+			}
+
+			lastRun = runDue(now, lastRun, cfg, Send)
+		}
+	}()
+
+	log.Println("摘要定时任务已启动")
+}
@@ -0,0 +1,142 @@
+package digest
+
+import (
+	"Puff/internal/config"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("缺少时区数据 %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestLastScheduled(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name   string
+		cfg    config.Config
+		now    time.Time
+		want   time.Time
+		period time.Duration
+		ok     bool
+	}{
+		{
+			name: "关闭",
+			cfg:  config.Config{DigestFrequency: "off"},
+			now:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "每日，尚未到当天的发送时间",
+			cfg:    config.Config{DigestFrequency: "daily", DigestTime: "09:00", DigestTimezone: "Asia/Shanghai"},
+			now:    time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC), // 北京时间 08:30
+			want:   time.Date(2024, 1, 1, 9, 0, 0, 0, shanghai),
+			period: 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "每日，正好到达发送时间",
+			cfg:    config.Config{DigestFrequency: "daily", DigestTime: "09:00", DigestTimezone: "Asia/Shanghai"},
+			now:    time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 1, 2, 9, 0, 0, 0, shanghai),
+			period: 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "每日，UTC 日期与配置时区不同",
+			cfg:    config.Config{DigestFrequency: "Daily", DigestTime: "23:30", DigestTimezone: "Asia/Shanghai"},
+			now:    time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC), // 北京时间 1 月 2 日 00:00
+			want:   time.Date(2024, 1, 1, 23, 30, 0, 0, shanghai),
+			period: 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "每日，夏令时开始当天",
+			cfg:    config.Config{DigestFrequency: "daily", DigestTime: "09:00", DigestTimezone: "America/New_York"},
+			now:    time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+			period: 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "每周一",
+			cfg:    config.Config{DigestFrequency: "weekly", DigestTime: "08:00", DigestTimezone: "UTC", DigestWeekday: 1},
+			now:    time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC), // 周三
+			want:   time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			period: 7 * 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "每周一，当天尚未到发送时间",
+			cfg:    config.Config{DigestFrequency: "weekly", DigestTime: "08:00", DigestTimezone: "UTC", DigestWeekday: 1},
+			now:    time.Date(2024, 1, 8, 7, 59, 0, 0, time.UTC),
+			want:   time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			period: 7 * 24 * time.Hour,
+			ok:     true,
+		},
+		{
+			name:   "未设置时间默认 09:00",
+			cfg:    config.Config{DigestFrequency: "daily", DigestTimezone: "UTC"},
+			now:    time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			period: 24 * time.Hour,
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, period, ok := lastScheduled(tt.now, &tt.cfg)
+		if ok != tt.ok || !got.Equal(tt.want) || period != tt.period {
+			t.Errorf("%s: lastScheduled = %s %s %t，期望 %s %s %t", tt.name, got, period, ok, tt.want, tt.period, tt.ok)
+		}
+	}
+}
+
+func TestRunDueRetriesFailedSend(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	cfg := &config.Config{DigestFrequency: "daily", DigestTime: "09:00", DigestTimezone: "UTC"}
+
+	var sent []time.Time
+	fail := true
+	send := func(start, end time.Time, cfg *config.Config) error {
+		if fail {
+			return errors.New("smtp 暂时不可用")
+		}
+		sent = append(sent, end)
+		return nil
+	}
+
+	lastRun := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	// 发送失败时不记录，下一分钟重试
+	if got := runDue(now, lastRun, cfg, send); !got.Equal(lastRun) {
+		t.Fatalf("发送失败后记录了发送时间 %s", got)
+	}
+	if _, err := os.Stat(lastRunPath()); !os.IsNotExist(err) {
+		t.Fatalf("发送失败后保存了发送记录: %v", err)
+	}
+
+	fail = false
+	now = now.Add(time.Minute)
+	lastRun = runDue(now, lastRun, cfg, send)
+	if !lastRun.Equal(now) || len(sent) != 1 || !sent[0].Equal(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("重试后 lastRun %s，已发送 %v", lastRun, sent)
+	}
+	if saved := loadLastRun(); !saved.Equal(now) {
+		t.Errorf("保存的发送时间 %s，期望 %s", saved, now)
+	}
+
+	// 同一周期不再发送
+	if runDue(now.Add(time.Minute), lastRun, cfg, send); len(sent) != 1 {
+		t.Errorf("同一周期重复发送 %v", sent)
+	}
+}
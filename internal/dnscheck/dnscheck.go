@@ -0,0 +1,312 @@
+package dnscheck
+
+import (
+	"Puff/internal/domainname"
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Result 是 DNS 检查的结论
+type Result string
+
+const (
+	Delegated   Result = "delegated"   // 注册局返回了 NS 委派，域名必然已注册
+	NXDomain    Result = "nxdomain"    // 注册局返回 NXDOMAIN，域名可能未注册，也可能被暂停解析
+	Undelegated Result = "undelegated" // 域名存在但没有委派
+	Unknown     Result = "unknown"     // 查询失败
+)
+
+const tldCacheTTL = time.Hour
+
+// 单次查询的超时时间
+var queryTimeout = 3 * time.Second
+
+type tldServers struct {
+	addrs   []string
+	expires time.Time
+}
+
+var (
+	tldCache   = make(map[string]tldServers)
+	tldCacheMu sync.Mutex
+)
+
+// Check 向 TLD 的权威服务器查询域名的 NS 委派。
+// resolvers 用于查找 TLD 的权威服务器，为空时使用系统的 /etc/resolv.conf；
+// 无法获得权威服务器时，直接向 resolvers 递归查询。
+func Check(domain string, resolvers []string) (Result, error) {
+	if len(resolvers) == 0 {
+		resolvers = systemResolvers()
+	}
+	if len(resolvers) == 0 {
+		return Unknown, errors.New("未配置 DNS 解析服务器")
+	}
+
+	name := domainname.ToASCII(domain)
+	tld := name
+	if i := strings.Index(name, "."); i >= 0 {
+		tld = name[i+1:]
+	}
+
+	servers, err := authoritativeServers(tld, resolvers)
+	recursive := false
+	if err != nil || len(servers) == 0 {
+		servers, recursive = resolvers, true
+	}
+
+	var lastErr error
+	for _, server := range servers {
+		result, err := checkWith(name, server, recursive)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+	}
+
+	// 所有权威服务器都不可用时，退回到递归查询
+	if !recursive {
+		for _, resolver := range resolvers {
+			result, err := checkWith(name, resolver, true)
+			if err == nil {
+				return result, nil
+			}
+			lastErr = err
+		}
+	}
+	return Unknown, lastErr
+}
+
+// checkWith 向单个服务器查询域名的 NS 记录并判断结果
+func checkWith(name, server string, recursive bool) (Result, error) {
+	msg, err := exchange(server, name, dnsmessage.TypeNS, recursive)
+	if err != nil {
+		return Unknown, err
+	}
+
+	switch msg.RCode {
+	case dnsmessage.RCodeNameError:
+		return NXDomain, nil
+	case dnsmessage.RCodeSuccess:
+	default:
+		return Unknown, fmt.Errorf("%s 返回 %s", server, msg.RCode)
+	}
+
+	// 权威服务器以 Authority 段的 NS 记录返回委派，递归查询则在 Answer 段
+	for _, rr := range append(msg.Answers, msg.Authorities...) {
+		if rr.Header.Type == dnsmessage.TypeNS && strings.EqualFold(strings.TrimSuffix(rr.Header.Name.String(), "."), name) {
+			return Delegated, nil
+		}
+	}
+	return Undelegated, nil
+}
+
+// authoritativeServers 返回 TLD 权威服务器的地址，结果会缓存一段时间
+func authoritativeServers(tld string, resolvers []string) ([]string, error) {
+	tldCacheMu.Lock()
+	cached, ok := tldCache[tld]
+	tldCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+
+	var (
+		msg *dnsmessage.Message
+		err error
+	)
+	for _, resolver := range resolvers {
+		if msg, err = exchange(resolver, tld, dnsmessage.TypeNS, true); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 优先使用附加段中的地址，缺失时再单独解析
+	glue := make(map[string]string)
+	for _, rr := range msg.Additionals {
+		if a, ok := rr.Body.(*dnsmessage.AResource); ok {
+			glue[strings.ToLower(rr.Header.Name.String())] = net.IP(a.A[:]).String()
+		}
+	}
+
+	var addrs []string
+	for _, rr := range msg.Answers {
+		ns, ok := rr.Body.(*dnsmessage.NSResource)
+		if !ok {
+			continue
+		}
+		host := strings.ToLower(ns.NS.String())
+		ip, ok := glue[host]
+		if !ok {
+			if ip, err = lookupA(host, resolvers); err != nil {
+				continue
+			}
+		}
+		addrs = append(addrs, net.JoinHostPort(ip, "53"))
+	}
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+
+	if len(addrs) > 0 {
+		tldCacheMu.Lock()
+		tldCache[tld] = tldServers{addrs: addrs, expires: time.Now().Add(tldCacheTTL)}
+		tldCacheMu.Unlock()
+	}
+	return addrs, nil
+}
+
+func lookupA(host string, resolvers []string) (string, error) {
+	var lastErr error
+	for _, resolver := range resolvers {
+		msg, err := exchange(resolver, strings.TrimSuffix(host, "."), dnsmessage.TypeA, true)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, rr := range msg.Answers {
+			if a, ok := rr.Body.(*dnsmessage.AResource); ok {
+				return net.IP(a.A[:]).String(), nil
+			}
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("无法解析 %s", host)
+	}
+	return "", lastErr
+}
+
+// exchange 发送一次 DNS 查询，响应被截断时改用 TCP 重试
+func exchange(server, name string, qtype dnsmessage.Type, recursive bool) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, err
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: recursive},
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := exchangeUDP(server, packet)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, err
+	}
+	if msg.Truncated {
+		if response, err = exchangeTCP(server, packet); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, err
+		}
+	}
+	if msg.ID != id {
+		return nil, fmt.Errorf("%s 返回的 DNS 响应 ID 不匹配", server)
+	}
+	return &msg, nil
+}
+
+func exchangeUDP(server string, packet []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", server, queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(queryTimeout))
+
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func exchangeTCP(server string, packet []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", server, queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(queryTimeout))
+
+	// TCP 消息以两字节长度开头
+	framed := make([]byte, 2+len(packet))
+	binary.BigEndian.PutUint16(framed, uint16(len(packet)))
+	copy(framed[2:], packet)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ParseResolvers 解析逗号分隔的解析服务器列表，未指定端口时使用 53
+func ParseResolvers(s string) []string {
+	var resolvers []string
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(r); err != nil {
+			r = net.JoinHostPort(strings.Trim(r, "[]"), "53")
+		}
+		resolvers = append(resolvers, r)
+	}
+	return resolvers
+}
+
+// systemResolvers 读取 /etc/resolv.conf 中的解析服务器
+func systemResolvers() []string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var resolvers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			resolvers = append(resolvers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return resolvers
+}
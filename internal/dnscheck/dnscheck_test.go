@@ -0,0 +1,149 @@
+package dnscheck
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startResponder 启动本地 UDP DNS 服务器，answer 根据查询构造响应，返回 nil 时不回复
+func startResponder(t *testing.T, answer func(q dnsmessage.Question) *dnsmessage.Message) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			resp := answer(query.Questions[0])
+			if resp == nil {
+				continue
+			}
+			resp.ID = query.ID
+			resp.Response = true
+			resp.Questions = query.Questions
+			packet, err := resp.Pack()
+			if err != nil {
+				t.Errorf("打包响应失败: %v", err)
+				return
+			}
+			conn.WriteTo(packet, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func nsRecord(name, host string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name + "."), Type: dnsmessage.TypeNS, Class: dnsmessage.ClassINET, TTL: 300},
+		Body:   &dnsmessage.NSResource{NS: dnsmessage.MustNewName(host + ".")},
+	}
+}
+
+// zone 模拟一个 test 后缀：TLD 查询拒绝，使检查退回到递归查询
+func zone(q dnsmessage.Question) *dnsmessage.Message {
+	name := strings.TrimSuffix(q.Name.String(), ".")
+	msg := &dnsmessage.Message{}
+	switch name {
+	case "test":
+		msg.RCode = dnsmessage.RCodeRefused
+	case "delegated.test":
+		msg.Answers = []dnsmessage.Resource{nsRecord(name, "ns1.example.net")}
+	case "authority.test":
+		// 权威服务器以 Authority 段返回委派
+		msg.Authorities = []dnsmessage.Resource{nsRecord(name, "ns1.example.net")}
+	case "undelegated.test":
+		msg.Authoritative = true
+	case "broken.test":
+		msg.RCode = dnsmessage.RCodeServerFailure
+	default:
+		msg.RCode = dnsmessage.RCodeNameError
+	}
+	return msg
+}
+
+func TestCheck(t *testing.T) {
+	resolver := startResponder(t, zone)
+
+	tests := []struct {
+		domain string
+		want   Result
+		err    bool
+	}{
+		{"delegated.test", Delegated, false},
+		{"authority.test", Delegated, false},
+		{"undelegated.test", Undelegated, false},
+		{"missing.test", NXDomain, false},
+		{"broken.test", Unknown, true},
+	}
+
+	for _, tt := range tests {
+		got, err := Check(tt.domain, []string{resolver})
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("Check(%s) = %s, %v，期望 %s", tt.domain, got, err, tt.want)
+		}
+	}
+}
+
+func TestCheckFallsBackToUnknown(t *testing.T) {
+	timeout := queryTimeout
+	queryTimeout = 200 * time.Millisecond
+	defer func() { queryTimeout = timeout }()
+
+	silent := startResponder(t, func(dnsmessage.Question) *dnsmessage.Message { return nil })
+
+	// 关闭后的端口不会有响应
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.LocalAddr().String()
+	closed.Close()
+
+	for _, resolvers := range [][]string{{silent}, {closedAddr}, {silent, closedAddr}} {
+		start := time.Now()
+		got, err := Check("example.test", resolvers)
+		if got != Unknown || err == nil {
+			t.Errorf("解析服务器 %v: Check = %s, %v，期望 unknown 和错误", resolvers, got, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("解析服务器 %v: 查询耗时 %s", resolvers, elapsed)
+		}
+	}
+}
+
+func TestCheckSkipsFailingResolver(t *testing.T) {
+	timeout := queryTimeout
+	queryTimeout = 200 * time.Millisecond
+	defer func() { queryTimeout = timeout }()
+
+	silent := startResponder(t, func(dnsmessage.Question) *dnsmessage.Message { return nil })
+	working := startResponder(t, zone)
+
+	got, err := Check("delegated.test", []string{silent, working})
+	if err != nil || got != Delegated {
+		t.Errorf("Check = %s, %v，期望 delegated", got, err)
+	}
+}
+
+func TestParseResolvers(t *testing.T) {
+	got := ParseResolvers(" 1.1.1.1, 127.0.0.1:5353,,[::1], 2001:db8::1 ")
+	want := []string{"1.1.1.1:53", "127.0.0.1:5353", "[::1]:53", "[2001:db8::1]:53"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("ParseResolvers = %v，期望 %v", got, want)
+	}
+}
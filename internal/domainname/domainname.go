@@ -0,0 +1,105 @@
+package domainname
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+var ErrEmpty = errors.New("域名不能为空")
+
+// 按 IDNA 查找规则校验标签，同时限制 DNS 长度
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.ValidateLabels(true),
+	idna.StrictDomainName(true),
+	idna.VerifyDNSLength(true),
+	idna.Transitional(false),
+)
+
+// Clean 去除用户输入中的协议、路径、端口、用户信息和末尾的点
+func Clean(input string) string {
+	domain := strings.TrimSpace(input)
+	if i := strings.Index(domain, "://"); i >= 0 {
+		domain = domain[i+3:]
+	}
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	if i := strings.LastIndex(domain, "@"); i >= 0 {
+		domain = domain[i+1:]
+	}
+	if i := strings.LastIndex(domain, ":"); i >= 0 {
+		domain = domain[:i]
+	}
+	domain = strings.TrimRight(domain, ".")
+	return strings.ToLower(domain)
+}
+
+// Normalize 将输入转换为可注册域名（例如 https://www.Example.com/ -> example.com），
+// 并按 IDNA 规则校验每个标签。国际化域名返回 U-label 形式。
+func Normalize(input string) (string, error) {
+	domain := Clean(input)
+	if domain == "" {
+		return "", ErrEmpty
+	}
+	if strings.ContainsAny(domain, " \t") {
+		return "", fmt.Errorf("域名 %s 包含空白字符", input)
+	}
+
+	ascii, err := profile.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("域名 %s 无效: %v", input, err)
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+	if err != nil {
+		return "", fmt.Errorf("域名 %s 不是可注册的域名: %v", input, err)
+	}
+
+	// 统一使用 U-label 作为规范形式，这样 A-label 和 Unicode 输入会被视为同一个域名
+	unicode, err := profile.ToUnicode(registrable)
+	if err != nil {
+		return "", fmt.Errorf("域名 %s 无效: %v", input, err)
+	}
+	return unicode, nil
+}
+
+// ToASCII 返回域名的 A-label（Punycode）形式，用于 Whois 查询和服务器匹配。
+// 无法转换时原样返回。
+func ToASCII(domain string) string {
+	ascii, err := profile.ToASCII(strings.ToLower(domain))
+	if err != nil {
+		return domain
+	}
+	return ascii
+}
+
+// ToUnicode 返回域名的 U-label 形式，用于界面和通知显示。
+// 无法转换时原样返回。
+func ToUnicode(domain string) string {
+	unicode, err := profile.ToUnicode(strings.ToLower(domain))
+	if err != nil {
+		return domain
+	}
+	return unicode
+}
+
+// IsIDN 判断域名是否为国际化域名
+func IsIDN(domain string) bool {
+	return !isASCII(domain) || strings.Contains(ToASCII(domain), "xn--")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
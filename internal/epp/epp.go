@@ -0,0 +1,274 @@
+package epp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// 连接和会话参数
+const (
+	dialTimeout      = 15 * time.Second
+	commandTimeout   = 30 * time.Second
+	defaultKeepAlive = 5 * time.Minute
+	maxFrameSize     = 1 << 20
+)
+
+// Config 是 EPP 服务器的连接和登录信息
+type Config struct {
+	Address            string // host:port，通常为 700 端口
+	Username           string // clID
+	Password           string
+	CertFile           string // 客户端证书，部分注册局要求
+	KeyFile            string
+	CAFile             string // 自签名的服务器证书，如本地测试服务器
+	InsecureSkipVerify bool
+	KeepAlive          time.Duration // 空闲时发送 hello 的间隔，0 表示 5 分钟
+}
+
+// Client 维护一个登录后的 EPP 会话。命令按顺序执行，连接断开时会在下一条命令前重新登录
+type Client struct {
+	cfg Config
+
+	mu       sync.Mutex
+	conn     net.Conn
+	lastUsed time.Time
+	txID     int
+	stop     chan struct{}
+	closed   bool
+}
+
+// NewClient 创建客户端，首次执行命令时才建立连接
+func NewClient(cfg Config) *Client {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = defaultKeepAlive
+	}
+	c := &Client{cfg: cfg, stop: make(chan struct{})}
+	go c.keepAlive()
+	return c
+}
+
+// keepAlive 在会话空闲时发送 hello，避免服务器因超时断开连接
+func (c *Client) keepAlive() {
+	ticker := time.NewTicker(c.cfg.KeepAlive / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.conn != nil && time.Since(c.lastUsed) >= c.cfg.KeepAlive/2 {
+				if _, err := c.roundTrip(helloXML()); err != nil {
+					c.disconnect()
+				}
+			}
+			c.mu.Unlock()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Hello 发送 hello 并返回服务器的问候信息
+func (c *Client) Hello() (*Greeting, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureSession(); err != nil {
+		return nil, err
+	}
+	data, err := c.roundTrip(helloXML())
+	if err != nil {
+		c.disconnect()
+		return nil, err
+	}
+	return parseGreeting(data)
+}
+
+// Close 登出并关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.stop)
+
+	if c.conn == nil {
+		return nil
+	}
+	_, err := c.roundTrip(c.command(logoutCommand()))
+	c.disconnect()
+	return err
+}
+
+// execute 执行一条命令，连接已失效时重新登录后重试一次；调用方需持有 mu。
+// 非幂等的命令（如 create）在发送后连接出错时不重试，因为无法确定服务器是否已执行
+func (c *Client) execute(build func() []byte, idempotent bool) (*Response, error) {
+	if c.closed {
+		return nil, errors.New("EPP 客户端已关闭")
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.ensureSession(); err != nil {
+			return nil, err
+		}
+
+		data, err := c.roundTrip(build())
+		if err != nil {
+			c.disconnect()
+			if attempt == 0 && idempotent {
+				continue
+			}
+			return nil, err
+		}
+
+		resp, err := parseResponse(data)
+		if err != nil {
+			return nil, err
+		}
+		// 2xxx 中的会话类错误需要重新登录
+		if resp.sessionLost() {
+			c.disconnect()
+			if attempt == 0 {
+				continue
+			}
+		}
+		return resp, resp.Err()
+	}
+}
+
+// ensureSession 在没有连接时连接服务器并登录；调用方需持有 mu
+func (c *Client) ensureSession() error {
+	if c.conn != nil {
+		return nil
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", c.cfg.Address, tlsConfig)
+	if err != nil {
+		return fmt.Errorf("连接 EPP 服务器失败: %w", err)
+	}
+	c.conn = conn
+
+	// 服务器在连接建立后首先发送问候
+	conn.SetDeadline(time.Now().Add(commandTimeout))
+	data, err := readFrame(conn)
+	if err != nil {
+		c.disconnect()
+		return fmt.Errorf("读取 EPP 问候失败: %w", err)
+	}
+	greeting, err := parseGreeting(data)
+	if err != nil {
+		c.disconnect()
+		return err
+	}
+
+	data, err = c.roundTrip(c.command(loginCommand(c.cfg.Username, c.cfg.Password, greeting)))
+	if err != nil {
+		c.disconnect()
+		return fmt.Errorf("EPP 登录失败: %w", err)
+	}
+	resp, err := parseResponse(data)
+	if err == nil {
+		err = resp.Err()
+	}
+	if err != nil {
+		c.disconnect()
+		return fmt.Errorf("EPP 登录失败: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(c.cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 EPP 服务器地址 %s: %w", c.cfg.Address, err)
+	}
+	config := &tls.Config{ServerName: host, InsecureSkipVerify: c.cfg.InsecureSkipVerify}
+
+	if c.cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载 EPP 客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if c.cfg.CAFile != "" {
+		pem, err := os.ReadFile(c.cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 EPP 服务器 CA 证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("EPP 服务器 CA 证书无效")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// roundTrip 发送一帧并读取响应；调用方需持有 mu
+func (c *Client) roundTrip(request []byte) ([]byte, error) {
+	c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	if err := writeFrame(c.conn, request); err != nil {
+		return nil, err
+	}
+	data, err := readFrame(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	c.lastUsed = time.Now()
+	return data, nil
+}
+
+func (c *Client) disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// command 为命令加上客户端事务号
+func (c *Client) command(inner string) []byte {
+	c.txID++
+	return commandXML(inner, fmt.Sprintf("puff-%d-%d", time.Now().Unix(), c.txID))
+}
+
+// readFrame 读取一个 EPP 数据单元，长度前缀为包含自身 4 字节的总长度（RFC 5734）
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size < 4 || size > maxFrameSize {
+		return nil, fmt.Errorf("无效的 EPP 数据长度: %d", size)
+	}
+
+	data := make([]byte, size-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)))
+	copy(frame[4:], data)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,345 @@
+package epp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// standIn 是按 RFC 5734 分帧的本地 EPP 服务器，用于测试客户端
+type standIn struct {
+	addr   string
+	caFile string
+
+	mu       sync.Mutex
+	commands []string // 收到的命令名称，如 login、check
+	requests []string // 收到的原始请求
+	logins   int
+	// dropAfter 为命令名称时，服务器在回复该命令后关闭连接
+	dropAfter string
+}
+
+func startStandIn(t *testing.T) *standIn {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "epp.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &standIn{caFile: filepath.Join(t.TempDir(), "ca.pem")}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(s.caFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s.addr = ln.Addr().String()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+const greetingXML = `<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><greeting>
+<svID>Stand-in EPP</svID><svDate>2024-01-01T00:00:00Z</svDate>
+<svcMenu><version>1.0</version><lang>en</lang>
+<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+<svcExtension><extURI>urn:ietf:params:xml:ns:rgp-1.0</extURI></svcExtension>
+</svcMenu></greeting></epp>`
+
+var commandName = regexp.MustCompile(`<command><(\w+)`)
+
+func (s *standIn) serve(conn net.Conn) {
+	defer conn.Close()
+	if err := writeFrame(conn, []byte(greetingXML)); err != nil {
+		return
+	}
+
+	for {
+		data, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		request := string(data)
+
+		name := "hello"
+		if m := commandName.FindStringSubmatch(request); m != nil {
+			name = m[1]
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, name)
+		s.requests = append(s.requests, request)
+		if name == "login" {
+			s.logins++
+		}
+		drop := s.dropAfter == name
+		if drop {
+			s.dropAfter = ""
+		}
+		s.mu.Unlock()
+
+		response := greetingXML
+		if name != "hello" {
+			response = respond(name, request)
+		}
+		if err := writeFrame(conn, []byte(response)); err != nil || name == "logout" || drop {
+			return
+		}
+	}
+}
+
+func result(code int, msg, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><response>
+<result code="%d"><msg>%s</msg></result>%s
+<trID><svTRID>SV-1</svTRID></trID></response></epp>`, code, msg, body)
+}
+
+func respond(name, request string) string {
+	switch name {
+	case "login":
+		if !strings.Contains(request, "<pw>secret</pw>") ||
+			!strings.Contains(request, "<extURI>urn:ietf:params:xml:ns:rgp-1.0</extURI>") {
+			return result(2200, "Authentication error", "")
+		}
+		return result(1000, "Command completed successfully", "")
+	case "logout":
+		return result(1500, "Command completed successfully; ending session", "")
+	case "check":
+		return result(1000, "Command completed successfully", `<resData>
+<domain:chkData xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+<domain:cd><domain:name avail="1">free.test</domain:name></domain:cd>
+<domain:cd><domain:name avail="0">taken.test</domain:name><domain:reason>In use</domain:reason></domain:cd>
+<domain:cd><domain:name avail="false">reserved.test</domain:name><domain:reason>Reserved</domain:reason></domain:cd>
+</domain:chkData></resData>`)
+	case "info":
+		if strings.Contains(request, "missing.test") {
+			return result(2303, "Object does not exist", "")
+		}
+		// hosts="none" 时不返回名称服务器
+		ns := ""
+		if !strings.Contains(request, `hosts="none"`) {
+			ns = `<domain:ns><domain:hostObj>ns1.example.net</domain:hostObj><domain:hostObj>ns2.example.net</domain:hostObj></domain:ns>`
+		}
+		return result(1000, "Command completed successfully", `<resData>
+<domain:infData xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+<domain:name>taken.test</domain:name><domain:roid>TAKEN-TEST</domain:roid>
+<domain:status s="clientTransferProhibited"/><domain:status s="pendingDelete"/>`+ns+`
+<domain:clID>registrar-a</domain:clID>
+<domain:crDate>2020-01-02T03:04:05.0Z</domain:crDate><domain:exDate>2025-01-02T03:04:05.0Z</domain:exDate>
+</domain:infData></resData>
+<extension><rgp:infData xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:rgpStatus s="redemptionPeriod"/></rgp:infData></extension>`)
+	case "create":
+		if strings.Contains(request, "taken.test") {
+			return result(2302, "Object exists", "")
+		}
+		return result(1000, "Command completed successfully", `<resData>
+<domain:creData xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+<domain:name>free.test</domain:name>
+<domain:crDate>2024-01-01T00:00:00.0Z</domain:crDate><domain:exDate>2025-01-01T00:00:00.0Z</domain:exDate>
+</domain:creData></resData>`)
+	}
+	return result(2000, "Unknown command", "")
+}
+
+func (s *standIn) snapshot() ([]string, []string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...), append([]string(nil), s.requests...), s.logins
+}
+
+// drop 使服务器在回复下一条指定命令后关闭连接
+func (s *standIn) drop(command string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropAfter = command
+}
+
+func (s *standIn) client(password string) *Client {
+	return NewClient(Config{Address: s.addr, Username: "puff", Password: password, CAFile: s.caFile})
+}
+
+func TestClient(t *testing.T) {
+	s := startStandIn(t)
+	c := s.client("secret")
+
+	greeting, err := c.Hello()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if greeting.ServerID != "Stand-in EPP" {
+		t.Errorf("问候 %+v", greeting)
+	}
+
+	checks, err := c.Check("free.test", "taken.test", "reserved.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CheckResult{
+		{Name: "free.test", Available: true},
+		{Name: "taken.test", Reason: "In use"},
+		{Name: "reserved.test", Reason: "Reserved"},
+	}
+	if fmt.Sprint(checks) != fmt.Sprint(want) {
+		t.Errorf("check = %+v", checks)
+	}
+
+	info, err := c.Info("taken.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ROID != "TAKEN-TEST" || info.Registrar != "registrar-a" {
+		t.Errorf("info = %+v", info)
+	}
+	if strings.Join(info.NameServers, ",") != "ns1.example.net,ns2.example.net" {
+		t.Errorf("名称服务器 %v", info.NameServers)
+	}
+	if strings.Join(info.Statuses, ",") != "clientTransferProhibited,pendingDelete,redemptionPeriod" {
+		t.Errorf("状态 %v", info.Statuses)
+	}
+	if !info.Expiration.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("到期时间 %s", info.Expiration)
+	}
+
+	created, err := c.Create(CreateRequest{Name: "free.test", Years: 2, Contact: "C1", AuthInfo: "a<b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "free.test" || created.Pending || created.ServerTxID != "SV-1" {
+		t.Errorf("create = %+v", created)
+	}
+
+	// 失败的结果码以 *Error 返回，会话保持可用
+	_, err = c.Info("missing.test")
+	var eppErr *Error
+	if !errors.As(err, &eppErr) || eppErr.Code != 2303 {
+		t.Errorf("info missing.test err = %v", err)
+	}
+	if _, err := c.Create(CreateRequest{Name: "taken.test"}); !errors.As(err, &eppErr) || eppErr.Code != 2302 {
+		t.Errorf("create taken.test err = %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Check("free.test"); err == nil {
+		t.Error("关闭后的客户端应返回错误")
+	}
+
+	commands, requests, logins := s.snapshot()
+	if got := strings.Join(commands, " "); got != "login hello check info create info create logout" {
+		t.Errorf("命令顺序 %s", got)
+	}
+	if logins != 1 {
+		t.Errorf("登录 %d 次", logins)
+	}
+	for _, r := range requests {
+		if strings.Contains(r, "<info>") && !strings.Contains(r, `hosts="del"`) {
+			t.Errorf("info 请求没有要求返回委派的名称服务器: %s", r)
+		}
+		if strings.Contains(r, "<create>") && strings.Contains(r, "free.test") {
+			for _, part := range []string{`<domain:period unit="y">2</domain:period>`, `<domain:registrant>C1</domain:registrant>`, `<domain:pw>a&lt;b</domain:pw>`} {
+				if !strings.Contains(r, part) {
+					t.Errorf("create 请求缺少 %s", part)
+				}
+			}
+		}
+		if !strings.Contains(r, "<clTRID>") && strings.Contains(r, "<command>") {
+			t.Errorf("命令缺少 clTRID: %s", r)
+		}
+	}
+}
+
+func TestClientLoginFailure(t *testing.T) {
+	s := startStandIn(t)
+	c := s.client("wrong")
+	defer c.Close()
+
+	_, err := c.Check("free.test")
+	var eppErr *Error
+	if !errors.As(err, &eppErr) || eppErr.Code != 2200 {
+		t.Errorf("登录失败应返回 2200: %v", err)
+	}
+}
+
+func TestClientReconnects(t *testing.T) {
+	s := startStandIn(t)
+	c := s.client("secret")
+	defer c.Close()
+
+	s.drop("check")
+	if _, err := c.Check("free.test"); err != nil {
+		t.Fatal(err)
+	}
+	// 服务器已关闭连接，幂等的命令重新登录后重试
+	if _, err := c.Check("free.test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, logins := s.snapshot(); logins != 2 {
+		t.Errorf("登录 %d 次，期望 2", logins)
+	}
+}
+
+func TestClientDoesNotRetryCreate(t *testing.T) {
+	s := startStandIn(t)
+	c := s.client("secret")
+	defer c.Close()
+
+	s.drop("check")
+	if _, err := c.Check("free.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 连接在上一条命令后断开，create 发送失败后不重试
+	if _, err := c.Create(CreateRequest{Name: "free.test"}); err == nil {
+		t.Error("连接断开时 create 应返回错误")
+	}
+	commands, _, _ := s.snapshot()
+	if n := strings.Count(strings.Join(commands, " "), "create"); n != 0 {
+		t.Errorf("create 被发送 %d 次", n)
+	}
+}
@@ -0,0 +1,318 @@
+package epp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 命名空间
+const (
+	nsEPP    = "urn:ietf:params:xml:ns:epp-1.0"
+	nsDomain = "urn:ietf:params:xml:ns:domain-1.0"
+	nsRGP    = "urn:ietf:params:xml:ns:rgp-1.0"
+)
+
+// Greeting 是服务器的问候信息
+type Greeting struct {
+	ServerID   string   `xml:"greeting>svID"`
+	ServerDate string   `xml:"greeting>svDate"`
+	Versions   []string `xml:"greeting>svcMenu>version"`
+	ObjURIs    []string `xml:"greeting>svcMenu>objURI"`
+	ExtURIs    []string `xml:"greeting>svcMenu>svcExtension>extURI"`
+}
+
+// Result 是响应中的结果码和说明
+type Result struct {
+	Code    int    `xml:"code,attr"`
+	Message string `xml:"msg"`
+	Reason  string `xml:"extValue>reason"`
+}
+
+// Response 是命令的响应
+type Response struct {
+	Results []Result `xml:"response>result"`
+	ChkData struct {
+		Items []struct {
+			Name struct {
+				Value string `xml:",chardata"`
+				Avail string `xml:"avail,attr"`
+			} `xml:"name"`
+			Reason string `xml:"reason"`
+		} `xml:"cd"`
+	} `xml:"response>resData>chkData"`
+	InfData struct {
+		Name     string `xml:"name"`
+		ROID     string `xml:"roid"`
+		Statuses []struct {
+			Value string `xml:"s,attr"`
+		} `xml:"status"`
+		HostObjs  []string `xml:"ns>hostObj"`
+		HostAttrs []string `xml:"ns>hostAttr>hostName"`
+		ClientID  string   `xml:"clID"`
+		CrDate    string   `xml:"crDate"`
+		ExDate    string   `xml:"exDate"`
+	} `xml:"response>resData>infData"`
+	CreData struct {
+		Name   string `xml:"name"`
+		CrDate string `xml:"crDate"`
+		ExDate string `xml:"exDate"`
+	} `xml:"response>resData>creData"`
+	RGPStatuses []struct {
+		Value string `xml:"s,attr"`
+	} `xml:"response>extension>infData>rgpStatus"`
+	ServerTxID string `xml:"response>trID>svTRID"`
+}
+
+// Error 是服务器返回的失败结果（2xxx）
+type Error struct {
+	Code    int
+	Message string
+	Reason  string
+}
+
+func (e *Error) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("EPP 错误 %d: %s (%s)", e.Code, e.Message, e.Reason)
+	}
+	return fmt.Sprintf("EPP 错误 %d: %s", e.Code, e.Message)
+}
+
+// Err 在命令失败时返回 *Error
+func (r *Response) Err() error {
+	if len(r.Results) == 0 {
+		return &Error{Message: "响应中没有结果"}
+	}
+	res := r.Results[0]
+	if res.Code >= 2000 {
+		return &Error{Code: res.Code, Message: strings.TrimSpace(res.Message), Reason: strings.TrimSpace(res.Reason)}
+	}
+	return nil
+}
+
+// sessionLost 判断服务器是否已结束会话：2500 系列结果码表示服务器关闭了连接
+func (r *Response) sessionLost() bool {
+	for _, res := range r.Results {
+		if res.Code == 2002 || (res.Code >= 2500 && res.Code < 2600) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckResult 是 domain:check 中一个域名的结果
+type CheckResult struct {
+	Name      string
+	Available bool
+	Reason    string
+}
+
+// InfoResult 是 domain:info 的结果
+type InfoResult struct {
+	Name        string
+	ROID        string
+	Statuses    []string // 包括 RGP 扩展中的 redemptionPeriod、pendingDelete 等状态
+	NameServers []string
+	Registrar   string // 保荐注册商的 clID
+	Created     time.Time
+	Expiration  time.Time
+}
+
+// Check 查询域名是否可注册
+func (c *Client) Check(names ...string) ([]CheckResult, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.execute(func() []byte { return c.command(checkCommand(names)) }, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CheckResult, 0, len(resp.ChkData.Items))
+	for _, item := range resp.ChkData.Items {
+		results = append(results, CheckResult{
+			Name:      strings.TrimSpace(item.Name.Value),
+			Available: item.Name.Avail == "1" || item.Name.Avail == "true",
+			Reason:    strings.TrimSpace(item.Reason),
+		})
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("EPP 响应中没有 %s 的查询结果", strings.Join(names, ", "))
+	}
+	return results, nil
+}
+
+// Info 查询域名的状态、名称服务器和到期时间，非保荐注册商只能看到部分字段
+func (c *Client) Info(name string) (*InfoResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.execute(func() []byte { return c.command(infoCommand(name)) }, true)
+	if err != nil {
+		return nil, err
+	}
+
+	data := resp.InfData
+	info := &InfoResult{
+		Name:        strings.TrimSpace(data.Name),
+		ROID:        strings.TrimSpace(data.ROID),
+		Registrar:   strings.TrimSpace(data.ClientID),
+		NameServers: append(data.HostObjs, data.HostAttrs...),
+		Created:     parseDate(data.CrDate),
+		Expiration:  parseDate(data.ExDate),
+	}
+	for _, s := range data.Statuses {
+		info.Statuses = append(info.Statuses, s.Value)
+	}
+	for _, s := range resp.RGPStatuses {
+		info.Statuses = append(info.Statuses, s.Value)
+	}
+	return info, nil
+}
+
+// CreateRequest 是 domain:create 的参数
+type CreateRequest struct {
+	Name     string
+	Years    int
+	Contact  string // 同时作为注册人、管理和技术联系人，为空时不提交联系人
+	AuthInfo string // 转移密码
+}
+
+// CreateResult 是 domain:create 的结果
+type CreateResult struct {
+	Name       string
+	Pending    bool // 结果码 1001，注册局稍后完成
+	Created    time.Time
+	Expiration time.Time
+	ServerTxID string
+}
+
+// Create 注册域名
+func (c *Client) Create(req CreateRequest) (*CreateResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.execute(func() []byte { return c.command(createCommand(req)) }, false)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(resp.CreData.Name)
+	if name == "" {
+		name = req.Name
+	}
+	return &CreateResult{
+		Name:       name,
+		Pending:    resp.Results[0].Code == 1001,
+		Created:    parseDate(resp.CreData.CrDate),
+		Expiration: parseDate(resp.CreData.ExDate),
+		ServerTxID: strings.TrimSpace(resp.ServerTxID),
+	}, nil
+}
+
+func parseDate(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	return t
+}
+
+func parseGreeting(data []byte) (*Greeting, error) {
+	var g Greeting
+	if err := xml.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("解析 EPP 问候失败: %w", err)
+	}
+	if g.ServerID == "" && len(g.ObjURIs) == 0 {
+		return nil, fmt.Errorf("EPP 服务器没有返回问候")
+	}
+	return &g, nil
+}
+
+func parseResponse(data []byte) (*Response, error) {
+	var r Response
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("解析 EPP 响应失败: %w", err)
+	}
+	return &r, nil
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func helloXML() []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` +
+		`<epp xmlns="` + nsEPP + `"><hello/></epp>`)
+}
+
+func commandXML(inner, clTRID string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` +
+		`<epp xmlns="` + nsEPP + `"><command>` + inner +
+		`<clTRID>` + escape(clTRID) + `</clTRID></command></epp>`)
+}
+
+// loginCommand 登录并声明使用服务器问候中列出的对象和扩展，RGP 扩展用于在 domain:info 中获取赎回状态
+func loginCommand(username, password string, g *Greeting) string {
+	var b strings.Builder
+	b.WriteString(`<login><clID>` + escape(username) + `</clID><pw>` + escape(password) + `</pw>`)
+	b.WriteString(`<options><version>1.0</version><lang>en</lang></options><svcs>`)
+
+	objURIs := g.ObjURIs
+	if len(objURIs) == 0 {
+		objURIs = []string{nsDomain}
+	}
+	for _, uri := range objURIs {
+		b.WriteString(`<objURI>` + escape(uri) + `</objURI>`)
+	}
+	for _, uri := range g.ExtURIs {
+		if uri == nsRGP {
+			b.WriteString(`<svcExtension><extURI>` + nsRGP + `</extURI></svcExtension>`)
+		}
+	}
+	b.WriteString(`</svcs></login>`)
+	return b.String()
+}
+
+func logoutCommand() string {
+	return `<logout/>`
+}
+
+func checkCommand(names []string) string {
+	var b strings.Builder
+	b.WriteString(`<check><domain:check xmlns:domain="` + nsDomain + `">`)
+	for _, name := range names {
+		b.WriteString(`<domain:name>` + escape(name) + `</domain:name>`)
+	}
+	b.WriteString(`</domain:check></check>`)
+	return b.String()
+}
+
+func infoCommand(name string) string {
+	return `<info><domain:info xmlns:domain="` + nsDomain + `">` +
+		`<domain:name hosts="del">` + escape(name) + `</domain:name>` +
+		`</domain:info></info>`
+}
+
+func createCommand(req CreateRequest) string {
+	var b strings.Builder
+	b.WriteString(`<create><domain:create xmlns:domain="` + nsDomain + `">`)
+	b.WriteString(`<domain:name>` + escape(req.Name) + `</domain:name>`)
+	if req.Years > 0 {
+		fmt.Fprintf(&b, `<domain:period unit="y">%d</domain:period>`, req.Years)
+	}
+	if req.Contact != "" {
+		contact := escape(req.Contact)
+		b.WriteString(`<domain:registrant>` + contact + `</domain:registrant>`)
+		b.WriteString(`<domain:contact type="admin">` + contact + `</domain:contact>`)
+		b.WriteString(`<domain:contact type="tech">` + contact + `</domain:contact>`)
+	}
+	b.WriteString(`<domain:authInfo><domain:pw>` + escape(req.AuthInfo) + `</domain:pw></domain:authInfo>`)
+	b.WriteString(`</domain:create></create>`)
+	return b.String()
+}
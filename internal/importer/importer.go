@@ -0,0 +1,228 @@
+package importer
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"Puff/internal/whois"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 支持的导入格式
+const (
+	FormatAuto = "auto"
+	FormatText = "text" // 每行一个域名，或以空白、逗号分隔
+	FormatCSV  = "csv"  // 第一列为域名，可选 tags、note 列
+)
+
+// Record 是从导入数据中解析出的一条记录
+type Record struct {
+	Line   int
+	Domain string
+	Tags   []string
+	Note   string
+}
+
+// Issue 描述一条未能导入的记录
+type Issue struct {
+	Line   int    `json:"line"`
+	Input  string `json:"input"`
+	Reason string `json:"reason"`
+}
+
+// Report 是导入预览的结果
+type Report struct {
+	Valid       []config.DomainEntry `json:"valid"`
+	Invalid     []Issue              `json:"invalid"`
+	Duplicate   []Issue              `json:"duplicate"`
+	Unsupported []Issue              `json:"unsupported"`
+}
+
+// 注册商导出文件中可能出现的域名列名
+var domainColumns = []string{"domain", "domain name", "domain_name", "domainname", "name", "域名"}
+
+// Parse 解析导入数据
+func Parse(format string, data []byte) ([]Record, error) {
+	if format == "" || format == FormatAuto {
+		format = detectFormat(data)
+	}
+
+	switch format {
+	case FormatText:
+		return parseText(data), nil
+	case FormatCSV:
+		return parseCSV(data)
+	}
+	return nil, fmt.Errorf("不支持的导入格式: %s", format)
+}
+
+// detectFormat 仅在首行为包含域名列的表头时识别为 CSV，其余按文本处理
+func detectFormat(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	reader := csv.NewReader(strings.NewReader(firstLine))
+	reader.Comma = detectDelimiter(data)
+	reader.LazyQuotes = true
+	if header, err := reader.Read(); err == nil && len(header) > 1 && findColumn(header, domainColumns) >= 0 {
+		return FormatCSV
+	}
+	return FormatText
+}
+
+func parseText(data []byte) []Record {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t'
+		}) {
+			records = append(records, Record{Line: line, Domain: field})
+		}
+	}
+	return records
+}
+
+func parseCSV(data []byte) ([]Record, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // 去除 BOM
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comma = detectDelimiter(data)
+
+	rows, err := reader.ReadAll()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("解析 CSV 失败: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	// 识别表头，兼容注册商导出的资产列表
+	domainCol, tagsCol, noteCol := 0, -1, -1
+	start := 0
+	if col := findColumn(rows[0], domainColumns); col >= 0 {
+		domainCol = col
+		tagsCol = findColumn(rows[0], []string{"tags", "tag", "标签"})
+		noteCol = findColumn(rows[0], []string{"note", "notes", "备注"})
+		start = 1
+	} else if len(rows[0]) > 1 {
+		// 无表头时按 域名,标签,备注 的顺序读取
+		tagsCol = 1
+		if len(rows[0]) > 2 {
+			noteCol = 2
+		}
+	}
+
+	var records []Record
+	for i, row := range rows[start:] {
+		if domainCol >= len(row) || strings.TrimSpace(row[domainCol]) == "" {
+			continue
+		}
+		record := Record{Line: start + i + 1, Domain: row[domainCol]}
+		if tagsCol >= 0 && tagsCol < len(row) {
+			record.Tags = strings.FieldsFunc(row[tagsCol], func(r rune) bool {
+				return r == '|' || r == ';' || r == ',' || r == ' '
+			})
+		}
+		if noteCol >= 0 && noteCol < len(row) {
+			record.Note = strings.TrimSpace(row[noteCol])
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func detectDelimiter(data []byte) rune {
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	switch {
+	case strings.Contains(firstLine, "\t"):
+		return '\t'
+	case strings.Count(firstLine, ";") > strings.Count(firstLine, ","):
+		return ';'
+	}
+	return ','
+}
+
+func findColumn(header []string, names []string) int {
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for _, name := range names {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Preview 校验记录并与已有列表去重，不会修改任何配置
+func Preview(records []Record, existing []config.DomainEntry, whoisServers map[string]config.ServerList, defaults config.DomainEntry) *Report {
+	report := &Report{
+		Valid:       []config.DomainEntry{},
+		Invalid:     []Issue{},
+		Duplicate:   []Issue{},
+		Unsupported: []Issue{},
+	}
+
+	seen := make(map[string]bool, len(existing)+len(records))
+	for _, e := range existing {
+		seen[e.Name] = true
+	}
+
+	for _, r := range records {
+		domain, err := domainname.Normalize(r.Domain)
+		if err != nil {
+			report.Invalid = append(report.Invalid, Issue{Line: r.Line, Input: r.Domain, Reason: err.Error()})
+			continue
+		}
+		if seen[domain] {
+			report.Duplicate = append(report.Duplicate, Issue{Line: r.Line, Input: r.Domain, Reason: "域名已存在"})
+			continue
+		}
+		if _, ok := whois.FindServer(whoisServers, domain); !ok {
+			tld := domainname.ToUnicode(whois.GetTLD(domain))
+			report.Unsupported = append(report.Unsupported, Issue{Line: r.Line, Input: r.Domain, Reason: fmt.Sprintf("未配置 %s 的 Whois 服务器", tld)})
+			continue
+		}
+		seen[domain] = true
+
+		entry := copyEntry(defaults)
+		entry.Name = domain
+		entry.Tags = append(entry.Tags, r.Tags...)
+		if r.Note != "" {
+			entry.Note = r.Note
+		}
+		report.Valid = append(report.Valid, entry)
+	}
+
+	return report
+}
+
+// copyEntry 深拷贝默认设置，避免各条导入记录共用同一份预期状态和自动注册设置
+func copyEntry(e config.DomainEntry) config.DomainEntry {
+	e.Tags = append([]string{}, e.Tags...)
+	e.Channels = append([]string(nil), e.Channels...)
+	e.AlertStates = append([]string(nil), e.AlertStates...)
+	if e.Expected != nil {
+		expected := *e.Expected
+		expected.NameServers = append([]string(nil), expected.NameServers...)
+		expected.Locks = append([]string(nil), expected.Locks...)
+		e.Expected = &expected
+	}
+	if e.AutoRegister != nil {
+		autoRegister := *e.AutoRegister
+		e.AutoRegister = &autoRegister
+	}
+	return e
+}
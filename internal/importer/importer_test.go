@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"Puff/internal/config"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []Record
+	}{
+		{
+			name:   "文本按行和分隔符拆分，忽略注释和空行",
+			format: FormatAuto,
+			data:   "# 关注列表\nexample.com, example.net\n\nexample.org\texample.cn;例子.中国\n",
+			want: []Record{
+				{Line: 2, Domain: "example.com"},
+				{Line: 2, Domain: "example.net"},
+				{Line: 4, Domain: "example.org"},
+				{Line: 4, Domain: "example.cn"},
+				{Line: 4, Domain: "例子.中国"},
+			},
+		},
+		{
+			name:   "带表头的 CSV 按列名读取",
+			format: FormatAuto,
+			data:   "\xef\xbb\xbfNote,Domain Name,Tags\n自用,example.com,a|b\n,example.net,\n",
+			want: []Record{
+				{Line: 2, Domain: "example.com", Tags: []string{"a", "b"}, Note: "自用"},
+				{Line: 3, Domain: "example.net", Tags: []string{}},
+			},
+		},
+		{
+			name:   "注册商导出的分号分隔文件",
+			format: FormatAuto,
+			data:   "域名;到期时间;备注\nexample.com;2030-01-01;续费\n",
+			want:   []Record{{Line: 2, Domain: "example.com", Note: "续费"}},
+		},
+		{
+			name:   "无表头的 CSV 按 域名,标签,备注 读取",
+			format: FormatCSV,
+			data:   "example.com,a b,备注\nexample.net\n",
+			want: []Record{
+				{Line: 1, Domain: "example.com", Tags: []string{"a", "b"}, Note: "备注"},
+				{Line: 2, Domain: "example.net"},
+			},
+		},
+		{
+			name:   "无表头的多列文本不识别为 CSV",
+			format: FormatAuto,
+			data:   "example.com,example.net\n",
+			want:   []Record{{Line: 1, Domain: "example.com"}, {Line: 1, Domain: "example.net"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse("xlsx", nil); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	servers := map[string]config.ServerList{
+		"com": {{Host: "whois.verisign-grs.com"}},
+		"中国":  {{Host: "cwhois.cnnic.cn"}},
+	}
+	existing := []config.DomainEntry{{Name: "example.com"}}
+	records := []Record{
+		{Line: 1, Domain: "WWW.Example.COM"},
+		{Line: 2, Domain: "new.com", Tags: []string{"a"}},
+		{Line: 3, Domain: "new.com"},
+		{Line: 4, Domain: "not a domain"},
+		{Line: 5, Domain: "example.xyz"},
+		{Line: 6, Domain: "xn--fsqu00a.xn--fiqs8s", Note: "中文"},
+	}
+	defaults := config.DomainEntry{
+		Tags:         []string{"导入"},
+		Note:         "默认备注",
+		Expected:     &config.ExpectedState{Registrar: "Example Registrar", NameServers: []string{"NS1.EXAMPLE.COM"}},
+		AutoRegister: &config.AutoRegister{Enabled: true, Registrar: "mock", MaxPrice: 10},
+	}
+
+	report := Preview(records, existing, servers, defaults)
+
+	issues := func(list []Issue) []int {
+		var lines []int
+		for _, i := range list {
+			lines = append(lines, i.Line)
+		}
+		return lines
+	}
+	if got := issues(report.Duplicate); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("重复记录 %v", got)
+	}
+	if got := issues(report.Invalid); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("无效记录 %v", got)
+	}
+	if got := issues(report.Unsupported); !reflect.DeepEqual(got, []int{5}) {
+		t.Errorf("不支持的记录 %v", got)
+	}
+	if len(report.Valid) != 2 {
+		t.Fatalf("有效记录 %+v", report.Valid)
+	}
+
+	first, second := report.Valid[0], report.Valid[1]
+	if first.Name != "new.com" || !reflect.DeepEqual(first.Tags, []string{"导入", "a"}) || first.Note != "默认备注" {
+		t.Errorf("第一条记录 %+v", first)
+	}
+	if second.Name != "例子.中国" || second.Note != "中文" || !reflect.DeepEqual(second.Tags, []string{"导入"}) {
+		t.Errorf("第二条记录 %+v", second)
+	}
+
+	// 规范化一条记录不能影响其他记录和默认设置
+	first.Expected.Registrar = "changed"
+	first.Expected.NameServers[0] = "changed"
+	first.AutoRegister.MaxPrice = 99
+	if err := first.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []config.DomainEntry{second, defaults} {
+		if e.Expected.Registrar != "Example Registrar" || e.Expected.NameServers[0] != "NS1.EXAMPLE.COM" || e.AutoRegister.MaxPrice != 10 {
+			t.Errorf("记录之间共用了默认设置: %+v %+v", e.Expected, e.AutoRegister)
+		}
+	}
+	if !reflect.DeepEqual(defaults.Tags, []string{"导入"}) {
+		t.Errorf("默认标签被修改: %v", defaults.Tags)
+	}
+}
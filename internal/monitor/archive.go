@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"Puff/internal/archive"
+	"Puff/internal/config"
+	"Puff/internal/whois"
+	"log"
+	"strings"
+	"time"
+)
+
+// archiveResponse 存档本次查询的原始响应，并返回关键字段相对同一服务器上一次响应的变化。
+// 上一次的响应从存档读取，因此重启后也能继续比较；切换服务器不会被当作信息变化。
+func archiveResponse(result whois.DomainStatus, cfg *config.Config, now time.Time) []whois.FieldChange {
+	if result.Raw == "" {
+		return nil
+	}
+
+	previous, changed, err := archive.Store(result.Domain, result.Server, result.Raw, now, cfg.ArchiveRetentionDays)
+	if err != nil {
+		log.Printf("存档域名 %s 的 Whois 响应失败: %v", result.Domain, err)
+		return nil
+	}
+	if !changed {
+		return nil
+	}
+
+	changes := whois.ParseFields(previous).Changes(whois.ParseFields(result.Raw))
+	if len(changes) > 0 {
+		log.Printf("域名 %s 的 Whois 信息发生变化: %s", result.Domain, strings.Join(whois.FormatChanges(changes), "；"))
+	}
+	return changes
+}
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"Puff/internal/backorder"
+	"Puff/internal/config"
+	"Puff/internal/notifier"
+	"fmt"
+	"log"
+	"time"
+)
+
+// autoRegister 为开启自动注册的域名下单，并立即通知下单结果
+func autoRegister(entry config.DomainEntry, quote backorder.Quote, since time.Time, cfg *config.Config) {
+	order, err := backorder.Place(entry, quote, since)
+	if err != nil {
+		log.Printf("域名 %s 自动注册出错: %v", entry.Name, err)
+	}
+	if order == nil {
+		return
+	}
+
+	details := []string{fmt.Sprintf("注册商：%s，年限：%d 年", order.Registrar, order.Period)}
+	if order.OrderID != "" {
+		details = append(details, "订单号："+order.OrderID)
+	}
+	if order.Cost > 0 {
+		details = append(details, fmt.Sprintf("计入预算：%g", order.Cost))
+	}
+	if order.Error != "" {
+		details = append(details, "原因："+order.Error)
+	}
+
+	n := notifier.DomainNotification{
+		Domain:         entry.Name,
+		Status:         "自动注册" + order.StatusText(),
+		Urgent:         true,
+		Channels:       entry.Channels,
+		Priority:       entry.Priority,
+		Tags:           entry.Tags,
+		PreviousStatus: "可注册",
+		Changes:        details,
+	}
+	if err := notifier.Dispatch([]notifier.DomainNotification{n}, cfg); err != nil {
+		log.Printf("发送自动注册通知错误: %v", err)
+	}
+}
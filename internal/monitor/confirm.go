@@ -0,0 +1,217 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/dnscheck"
+	"Puff/internal/rdap"
+	"Puff/internal/registrar"
+	"Puff/internal/whois"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 确认可注册的来源
+const (
+	SourceWhois     = "whois"
+	SourceRDAP      = "rdap"
+	SourceDNS       = "dns"
+	SourceSecondary = "secondary"
+	// SourceRegistrar 是注册商 API 来源的前缀，完整写法为 registrar:名称
+	SourceRegistrar = "registrar:"
+)
+
+// 来源的判断结果
+const (
+	VerdictAvailable  = "available"
+	VerdictRegistered = "registered"
+	VerdictUnknown    = "unknown"
+)
+
+// Evidence 是一个来源对域名是否可注册的判断
+type Evidence struct {
+	Source  string
+	Verdict string
+	Detail  string
+}
+
+func (e Evidence) String() string {
+	verdict := map[string]string{
+		VerdictAvailable:  "可注册",
+		VerdictRegistered: "已注册",
+		VerdictUnknown:    "无法判断",
+	}[e.Verdict]
+	if e.Detail == "" {
+		return fmt.Sprintf("%s: %s", e.Source, verdict)
+	}
+	return fmt.Sprintf("%s: %s（%s）", e.Source, verdict, e.Detail)
+}
+
+// checkResult 是一次检查的结果，Evidence 仅在 Whois 判断为可注册时收集
+type checkResult struct {
+	whois.DomainStatus
+	Evidence []Evidence
+}
+
+// confirmSources 返回配置的确认来源，主 Whois 查询总是包含在内，未知的来源被忽略
+func confirmSources(cfg *config.Config) []string {
+	sources, err := config.ParseConfirmSources(cfg.ConfirmSources)
+	if err != nil {
+		log.Printf("确认来源配置错误，已忽略: %v", err)
+	}
+	return sources
+}
+
+// collectEvidence 在主 Whois 查询判断为可注册后，并发查询其他来源
+// servers 是域名使用的服务器列表，未配置备用服务器时从中选择其他服务器作为 secondary 来源
+func collectEvidence(entry config.DomainEntry, primary whois.DomainStatus, servers []config.WhoisServer, cfg *config.Config) []Evidence {
+	sources := confirmSources(cfg)
+	evidence := make([]Evidence, len(sources))
+	evidence[0] = Evidence{Source: SourceWhois, Verdict: verdictOf(primary.Registered), Detail: primary.Server}
+
+	var wg sync.WaitGroup
+	for i, source := range sources[1:] {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			evidence[i] = querySource(source, entry, primary.Server, servers, cfg)
+		}(i+1, source)
+	}
+	wg.Wait()
+	return evidence
+}
+
+func querySource(source string, entry config.DomainEntry, whoisServer string, servers []config.WhoisServer, cfg *config.Config) Evidence {
+	e := Evidence{Source: source, Verdict: VerdictUnknown}
+
+	switch source {
+	case SourceRDAP:
+		result, err := rdap.Query(entry.Name)
+		if err != nil {
+			e.Detail = err.Error()
+			return e
+		}
+		e.Verdict = verdictOf(result.Registered)
+		e.Detail = fmt.Sprintf("HTTP %d", result.StatusCode)
+
+	case SourceDNS:
+		result, err := dnscheck.Check(entry.Name, dnscheck.ParseResolvers(cfg.DNSResolvers))
+		if err != nil {
+			e.Detail = err.Error()
+			return e
+		}
+		// 未委派的域名可能已注册但被暂停解析，不作为可注册的依据
+		switch result {
+		case dnscheck.NXDomain:
+			e.Verdict = VerdictAvailable
+		case dnscheck.Delegated:
+			e.Verdict = VerdictRegistered
+		}
+		e.Detail = string(result)
+
+	case SourceSecondary:
+		secondaryServers, err := config.LoadSecondaryWhoisServers()
+		if err != nil {
+			e.Detail = err.Error()
+			return e
+		}
+		candidates, ok := whois.FindServer(secondaryServers, entry.Name)
+		if !ok {
+			candidates = servers
+		}
+		// 排除已给出结果的主服务器
+		var others []config.WhoisServer
+		for _, server := range candidates {
+			if server.Address() != whoisServer {
+				others = append(others, server)
+			}
+		}
+		if len(others) == 0 {
+			e.Detail = "未配置备用 Whois 服务器"
+			return e
+		}
+		status, err := whois.Query(entry.Name, others)
+		if err != nil {
+			e.Detail = err.Error()
+			return e
+		}
+		e.Verdict = verdictOf(status.Registered)
+		e.Detail = status.Server
+
+	default:
+		if len(source) <= len(SourceRegistrar) || !strings.EqualFold(source[:len(SourceRegistrar)], SourceRegistrar) {
+			e.Detail = "未知的来源"
+			return e
+		}
+		result, err := registrar.Check(source[len(SourceRegistrar):], entry.Name)
+		if err != nil {
+			e.Detail = err.Error()
+			return e
+		}
+		e.Verdict = verdictOf(!result.Available)
+		e.Detail = registrarDetail(result.Premium, result.Price)
+	}
+	return e
+}
+
+// registrarDetail 描述注册商返回的溢价标记和价格
+func registrarDetail(premium bool, price string) string {
+	var parts []string
+	if premium {
+		parts = append(parts, "溢价域名")
+	}
+	if price != "" {
+		parts = append(parts, price)
+	}
+	return strings.Join(parts, "，")
+}
+
+func verdictOf(registered bool) string {
+	if registered {
+		return VerdictRegistered
+	}
+	return VerdictAvailable
+}
+
+// confirmAvailable 判断 Whois 给出的可注册结果是否得到确认：
+// 至少 ConfirmRequired 个来源判断为可注册，或在时间窗口内连续 ConfirmConsecutive 次判断为可注册。
+// 调用方需持有 statusMutex。
+func confirmAvailable(status *DomainStatus, evidence []Evidence, cfg *config.Config, now time.Time) (bool, string) {
+	// 记录时间窗口内连续判断为可注册的次数
+	window := time.Duration(cfg.ConfirmWindowMinutes) * time.Minute
+	if status.AvailableStreak == 0 || now.Sub(status.AvailableStreakStart) > window {
+		status.AvailableStreak = 0
+		status.AvailableStreakStart = now
+	}
+	status.AvailableStreak++
+
+	votes := 0
+	for _, e := range evidence {
+		if e.Verdict == VerdictAvailable {
+			votes++
+		}
+	}
+
+	// 手动编辑的配置可能要求超过来源数量的确认，此时要求全部来源确认
+	required := cfg.ConfirmRequired
+	if required > len(evidence) {
+		required = len(evidence)
+	}
+	if votes >= required {
+		return true, fmt.Sprintf("%d/%d 个来源确认可注册", votes, len(evidence))
+	}
+	if cfg.ConfirmConsecutive > 0 && status.AvailableStreak >= cfg.ConfirmConsecutive {
+		return true, fmt.Sprintf("%d 分钟内连续 %d 次检测为可注册", cfg.ConfirmWindowMinutes, status.AvailableStreak)
+	}
+	return false, fmt.Sprintf("仅 %d/%d 个来源判断为可注册，需要 %d 个", votes, len(evidence), required)
+}
+
+func formatEvidence(evidence []Evidence) []string {
+	lines := make([]string, 0, len(evidence))
+	for _, e := range evidence {
+		lines = append(lines, e.String())
+	}
+	return lines
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"testing"
+	"time"
+)
+
+func TestConfirmAvailable(t *testing.T) {
+	available := Evidence{Source: SourceWhois, Verdict: VerdictAvailable}
+	registered := Evidence{Source: SourceRDAP, Verdict: VerdictRegistered}
+	unknown := Evidence{Source: SourceDNS, Verdict: VerdictUnknown}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type check struct {
+		after     time.Duration // 相对 start 的时间
+		evidence  []Evidence
+		confirmed bool
+	}
+	tests := []struct {
+		name   string
+		cfg    config.Config
+		checks []check
+	}{
+		{
+			name:   "只有 Whois 时一次确认",
+			cfg:    config.Config{ConfirmRequired: 1, ConfirmWindowMinutes: 30},
+			checks: []check{{0, []Evidence{available}, true}},
+		},
+		{
+			name: "多来源投票",
+			cfg:  config.Config{ConfirmRequired: 2, ConfirmWindowMinutes: 30},
+			checks: []check{
+				{0, []Evidence{available, registered, unknown}, false},
+				{time.Minute, []Evidence{available, {Source: SourceRDAP, Verdict: VerdictAvailable}, unknown}, true},
+			},
+		},
+		{
+			name: "所需数量超过来源数量时要求全部来源确认",
+			cfg:  config.Config{ConfirmRequired: 3, ConfirmWindowMinutes: 30},
+			checks: []check{
+				{0, []Evidence{available, registered}, false},
+				{time.Minute, []Evidence{available, {Source: SourceRDAP, Verdict: VerdictAvailable}}, true},
+			},
+		},
+		{
+			name: "窗口内连续检测为可注册",
+			cfg:  config.Config{ConfirmRequired: 2, ConfirmConsecutive: 3, ConfirmWindowMinutes: 30},
+			checks: []check{
+				{0, []Evidence{available, registered}, false},
+				{10 * time.Minute, []Evidence{available, registered}, false},
+				{20 * time.Minute, []Evidence{available, registered}, true},
+			},
+		},
+		{
+			name: "超出窗口后重新计数",
+			cfg:  config.Config{ConfirmRequired: 2, ConfirmConsecutive: 2, ConfirmWindowMinutes: 30},
+			checks: []check{
+				{0, []Evidence{available, unknown}, false},
+				{31 * time.Minute, []Evidence{available, unknown}, false},
+				{40 * time.Minute, []Evidence{available, unknown}, true},
+			},
+		},
+		{
+			name: "未开启连续检测时只看投票",
+			cfg:  config.Config{ConfirmRequired: 2, ConfirmWindowMinutes: 30},
+			checks: []check{
+				{0, []Evidence{available, unknown}, false},
+				{time.Minute, []Evidence{available, unknown}, false},
+				{2 * time.Minute, []Evidence{available, unknown}, false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		status := &DomainStatus{}
+		for i, c := range tt.checks {
+			confirmed, reason := confirmAvailable(status, c.evidence, &tt.cfg, start.Add(c.after))
+			if confirmed != c.confirmed {
+				t.Errorf("%s 第 %d 次: confirmed = %t（%s）", tt.name, i+1, confirmed, reason)
+			}
+		}
+	}
+}
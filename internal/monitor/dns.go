@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/dnscheck"
+	"log"
+	"time"
+)
+
+// dnsPrecheck 查询域名的 DNS 委派并记录到状态中，返回本轮是否可以跳过 Whois 查询。
+// 只有上一次 Whois 确认为已注册、且 DNS 仍有委派的域名才会跳过，并且每隔 DNSWhoisEvery 轮
+// 仍会执行一次 Whois 查询；NXDOMAIN 不会直接判定为可注册，始终需要 Whois 确认。
+func dnsPrecheck(entry config.DomainEntry, cfg *config.Config, allowSkip bool) bool {
+	result, err := dnscheck.Check(entry.Name, dnscheck.ParseResolvers(cfg.DNSResolvers))
+	if err != nil {
+		log.Printf("DNS 查询域名 %s 失败: %v", entry.Name, err)
+	}
+
+	now := time.Now()
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	status, exists := domainStatuses[entry.Name]
+	if !exists {
+		status = &DomainStatus{Domain: entry.Name}
+		domainStatuses[entry.Name] = status
+	}
+	status.DNSResult = string(result)
+	status.DNSChecked = now
+
+	if !allowSkip || result != dnscheck.Delegated || status.LastWhois.IsZero() ||
+		!status.Registered || status.Redemption || status.PendingDelete ||
+		status.WhoisSkipped+1 >= cfg.DNSWhoisEvery {
+		return false
+	}
+
+	status.WhoisSkipped++
+	status.LastChecked = now
+	log.Printf("域名 %s 的 DNS 仍有委派，跳过本轮 Whois 查询（%d/%d）", entry.Name, status.WhoisSkipped, cfg.DNSWhoisEvery)
+	return true
+}
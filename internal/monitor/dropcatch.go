@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/whois"
+	"log"
+	"sync"
+	"time"
+)
+
+// 抢注轮询参数
+const (
+	dropCatchScan     = 10 * time.Second // 检查哪些域名进入预计删除时间窗口的间隔
+	dropCatchLogEvery = time.Minute      // 轮询出错时日志的最小间隔
+
+	// 配置缺失或无效时使用的值，与 LoadConfig 的默认值一致
+	defaultDropCatchInterval = time.Second
+	minDropCatchInterval     = 100 * time.Millisecond
+	defaultDropCatchRate     = 5
+	defaultDropCatchWindow   = 6 * time.Hour
+)
+
+// dropCatchSettings 返回抢注轮询的每秒查询上限、轮询间隔和时间窗口，
+// 未经 LoadConfig 补全默认值的配置（如直接从请求解析的设置）同样可以安全使用
+func dropCatchSettings(cfg *config.Config) (int, time.Duration, time.Duration) {
+	rate := cfg.DropCatchRate
+	if rate < 1 {
+		rate = defaultDropCatchRate
+	}
+	interval := time.Duration(cfg.DropCatchIntervalMs) * time.Millisecond
+	if interval < minDropCatchInterval {
+		interval = defaultDropCatchInterval
+	}
+	window := time.Duration(cfg.DropCatchWindowHours) * time.Hour
+	if window <= 0 {
+		window = defaultDropCatchWindow
+	}
+	return rate, interval, window
+}
+
+var (
+	catching = make(map[string]bool) // 正在高频轮询的域名
+	catchMu  sync.Mutex
+)
+
+// rateLimiter 是所有抢注轮询共享的查询配额
+type rateLimiter struct {
+	tokens chan struct{}
+}
+
+func newRateLimiter(perSecond int, stop <-chan struct{}) *rateLimiter {
+	l := &rateLimiter{tokens: make(chan struct{}, perSecond)}
+	go func() {
+		ticker := time.NewTicker(time.Second / time.Duration(perSecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case l.tokens <- struct{}{}:
+				default:
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return l
+}
+
+// wait 等待一次查询配额，监控停止时返回 false
+func (l *rateLimiter) wait(stop <-chan struct{}) bool {
+	select {
+	case <-l.tokens:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// runDropCatch 定期找出处于预计删除时间窗口内的抢注域名，为每个域名启动高频轮询
+func runDropCatch(whoisServers map[string]config.ServerList, cfg *config.Config, stop <-chan struct{}) {
+	rate, interval, window := dropCatchSettings(cfg)
+	limiter := newRateLimiter(rate, stop)
+	ticker := time.NewTicker(dropCatchScan)
+	defer ticker.Stop()
+
+	for {
+		entries, err := config.LoadDomainEntries()
+		if err != nil {
+			log.Printf("加载域名列表失败: %v", err)
+		}
+		now := time.Now()
+		for _, e := range entries {
+			if !e.DropCatch {
+				continue
+			}
+			until, ok := dropWindowEnd(e.Name, window, now)
+			if !ok {
+				continue
+			}
+
+			catchMu.Lock()
+			if !catching[e.Name] {
+				catching[e.Name] = true
+				go pollDrop(e, whoisServers, cfg, limiter, interval, until, stop)
+			}
+			catchMu.Unlock()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// dropWindowEnd 判断域名的预计删除时间窗口是否已开始，返回窗口结束的时间
+func dropWindowEnd(domain string, window time.Duration, now time.Time) (time.Time, bool) {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	status, exists := domainStatuses[domain]
+	if !exists || status.PredictedDropDate.IsZero() {
+		return time.Time{}, false
+	}
+	start := status.PredictedDropDate.Add(-window)
+	end := status.PredictedDropDate.Add(window)
+	return end, !now.Before(start) && now.Before(end)
+}
+
+// isCatching 判断域名是否正在高频轮询，常规检查会跳过这些域名
+func isCatching(domain string) bool {
+	catchMu.Lock()
+	defer catchMu.Unlock()
+	return catching[domain]
+}
+
+// pollDrop 在时间窗口内按固定间隔查询域名，域名释放或被续费、注册后立即交给常规的确认、
+// 通知和自动注册流程处理，并停止轮询
+func pollDrop(entry config.DomainEntry, whoisServers map[string]config.ServerList, cfg *config.Config, limiter *rateLimiter, interval time.Duration, until time.Time, stop <-chan struct{}) {
+	setDropCatching(entry.Name, true)
+	defer func() {
+		setDropCatching(entry.Name, false)
+		catchMu.Lock()
+		delete(catching, entry.Name)
+		catchMu.Unlock()
+	}()
+
+	servers, err := resolveServer(entry, whoisServers)
+	if err != nil {
+		log.Printf("域名 %s 无法开始抢注轮询: %v", entry.Name, err)
+		return
+	}
+
+	log.Printf("域名 %s 进入预计删除时间窗口，开始每 %v 轮询一次，直到 %s", entry.Name, interval, until.Format("2006-01-02 15:04:05"))
+
+	var polls int
+	var lastErrorLog time.Time
+	for time.Now().Before(until) {
+		if !limiter.wait(stop) {
+			return
+		}
+		polls++
+
+		status, err := whois.Query(entry.Name, servers)
+		switch {
+		case err != nil:
+			if time.Since(lastErrorLog) >= dropCatchLogEvery {
+				log.Printf("抢注轮询域名 %s 出错: %v", entry.Name, err)
+				lastErrorLog = time.Now()
+			}
+		case status.Registered && (status.PendingDelete || status.Redemption):
+			touchLastChecked(entry.Name)
+		default:
+			state := "已注册"
+			if status.Restriction != "" {
+				state = restrictionText(status.Restriction)
+			} else if !status.Registered {
+				state = "可注册"
+			}
+			log.Printf("域名 %s 在第 %d 次抢注轮询时变为%s", entry.Name, polls, state)
+			result := checkResult{DomainStatus: status}
+			if alertable(entry, status) {
+				result.Evidence = collectEvidence(entry, status, servers, cfg)
+			}
+			results := make(chan checkResult, 1)
+			results <- result
+			close(results)
+			processResults(results, map[string]config.DomainEntry{entry.Name: entry}, cfg)
+
+			// 可注册尚未得到确认时继续轮询
+			if !pendingConfirmation(entry.Name) {
+				return
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-stop:
+			return
+		}
+	}
+	log.Printf("域名 %s 的预计删除时间窗口已结束，共轮询 %d 次，恢复常规检查", entry.Name, polls)
+}
+
+func touchLastChecked(domain string) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	if status, exists := domainStatuses[domain]; exists {
+		status.LastChecked = time.Now()
+	}
+}
+
+func setDropCatching(domain string, catching bool) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	if status, exists := domainStatuses[domain]; exists {
+		status.DropCatching = catching
+	}
+}
+
+func pendingConfirmation(domain string) bool {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+	status, exists := domainStatuses[domain]
+	return exists && status.PendingConfirmation
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"testing"
+	"time"
+)
+
+func TestDropCatchSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		rate     int
+		interval time.Duration
+		window   time.Duration
+	}{
+		{"未设置时使用默认值", config.Config{}, defaultDropCatchRate, defaultDropCatchInterval, defaultDropCatchWindow},
+		{"负数使用默认值", config.Config{DropCatchRate: -1, DropCatchIntervalMs: -5, DropCatchWindowHours: -1}, defaultDropCatchRate, defaultDropCatchInterval, defaultDropCatchWindow},
+		{"间隔过短使用默认值", config.Config{DropCatchRate: 2, DropCatchIntervalMs: 10, DropCatchWindowHours: 3}, 2, defaultDropCatchInterval, 3 * time.Hour},
+		{"使用配置值", config.Config{DropCatchRate: 10, DropCatchIntervalMs: 500, DropCatchWindowHours: 12}, 10, 500 * time.Millisecond, 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		rate, interval, window := dropCatchSettings(&tt.cfg)
+		if rate != tt.rate || interval != tt.interval || window != tt.window {
+			t.Errorf("%s: %d %s %s", tt.name, rate, interval, window)
+		}
+	}
+}
+
+func TestStatusNotificationUrgency(t *testing.T) {
+	catch := config.DomainEntry{Name: "example.com", DropCatch: true}
+	watch := config.DomainEntry{Name: "example.com"}
+
+	tests := []struct {
+		name   string
+		entry  config.DomainEntry
+		status DomainStatus
+		urgent bool
+	}{
+		{"抢注域名首次检测为可注册", catch, DomainStatus{Domain: "example.com"}, true},
+		{"普通域名首次检测为可注册", watch, DomainStatus{Domain: "example.com"}, false},
+		{"普通域名的最终通知", watch, DomainStatus{Domain: "example.com", IsFinalNotice: true}, true},
+		{"抢注域名进入待删除", catch, DomainStatus{Domain: "example.com", Registered: true, PendingDelete: true}, false},
+	}
+	for _, tt := range tests {
+		if n := statusNotification(tt.entry, &tt.status); n.Urgent != tt.urgent {
+			t.Errorf("%s: Urgent = %t，期望 %t", tt.name, n.Urgent, tt.urgent)
+		}
+	}
+}
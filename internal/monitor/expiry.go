@@ -0,0 +1,184 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/notifier"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reminderState 记录自有域名在当前续费周期内已发送的提醒
+type reminderState struct {
+	Expiry    time.Time       `json:"expiry"`
+	Sent      map[int]bool    `json:"sent"`
+	Escalated map[string]bool `json:"escalated"`
+}
+
+// reminderStates 保存在文件中，重启后不会重复发送当前档位的提醒；首次使用时加载
+var reminderStates map[string]*reminderState
+
+func remindersPath() string {
+	return config.GetConfigPath("expiry_reminders.json")
+}
+
+func loadReminderStates() map[string]*reminderState {
+	states := make(map[string]*reminderState)
+	content, err := os.ReadFile(remindersPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取到期提醒记录失败: %v", err)
+		}
+		return states
+	}
+	if err := json.Unmarshal(content, &states); err != nil {
+		log.Printf("到期提醒记录无效: %v", err)
+		return make(map[string]*reminderState)
+	}
+	for _, state := range states {
+		if state.Sent == nil {
+			state.Sent = make(map[int]bool)
+		}
+		if state.Escalated == nil {
+			state.Escalated = make(map[string]bool)
+		}
+	}
+	return states
+}
+
+func saveReminderStates() {
+	content, err := json.MarshalIndent(reminderStates, "", "  ")
+	if err == nil {
+		err = os.WriteFile(remindersPath(), content, 0644)
+	}
+	if err != nil {
+		log.Printf("保存到期提醒记录失败: %v", err)
+	}
+}
+
+// reminderOffsets 解析提醒天数配置，按从大到小排序
+func reminderOffsets(cfg *config.Config) []int {
+	spec := cfg.ExpiryReminderDays
+	if spec == "" {
+		spec = "60,30,7,1"
+	}
+
+	var offsets []int
+	for _, field := range strings.Split(spec, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || days < 0 {
+			log.Printf("无效的到期提醒天数: %s", field)
+			continue
+		}
+		offsets = append(offsets, days)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	return offsets
+}
+
+// checkExpiryReminder 为自有域名生成到期提醒，调用方需持有 statusMutex
+func checkExpiryReminder(status *DomainStatus, cfg *config.Config, now time.Time) []notifier.DomainNotification {
+	if reminderStates == nil {
+		reminderStates = loadReminderStates()
+	}
+	changed := false
+	defer func() {
+		if changed {
+			saveReminderStates()
+		}
+	}()
+
+	if !status.Registered {
+		if _, exists := reminderStates[status.Domain]; exists {
+			delete(reminderStates, status.Domain)
+			changed = true
+		}
+		return nil
+	}
+
+	state, exists := reminderStates[status.Domain]
+	if !exists {
+		changed = true
+		state = &reminderState{
+			Expiry:    status.ExpirationDate,
+			Sent:      make(map[int]bool),
+			Escalated: make(map[string]bool),
+		}
+		reminderStates[status.Domain] = state
+	}
+
+	// 到期时间后移说明已经续费，开始新的提醒周期
+	if !status.ExpirationDate.IsZero() && status.ExpirationDate.After(state.Expiry) {
+		if !state.Expiry.IsZero() {
+			log.Printf("域名 %s 已续费，到期时间由 %s 变更为 %s", status.Domain,
+				state.Expiry.Format("2006-01-02"), status.ExpirationDate.Format("2006-01-02"))
+		}
+		state.Expiry = status.ExpirationDate
+		state.Sent = make(map[int]bool)
+		state.Escalated = make(map[string]bool)
+		changed = true
+	}
+
+	var notifications []notifier.DomainNotification
+
+	// 进入自动续费宽限期或赎回期时升级为紧急提醒
+	escalation := ""
+	switch {
+	case status.Redemption:
+		escalation = "已进入赎回期，请立即赎回"
+	case status.AutoRenew:
+		escalation = "已进入自动续费宽限期，请尽快续费"
+	}
+	if escalation != "" && !state.Escalated[escalation] {
+		state.Escalated[escalation] = true
+		changed = true
+		log.Printf("自有域名 %s %s", status.Domain, escalation)
+		notifications = append(notifications, notifier.DomainNotification{
+			Domain: status.Domain,
+			Status: escalation,
+			Urgent: true,
+		})
+		return notifications
+	}
+
+	if state.Expiry.IsZero() || status.Redemption || status.AutoRenew {
+		return notifications
+	}
+
+	daysLeft := int(math.Ceil(state.Expiry.Sub(now).Hours() / 24))
+	for _, offset := range reminderOffsets(cfg) {
+		if daysLeft > offset || state.Sent[offset] {
+			continue
+		}
+		state.Sent[offset] = true
+		changed = true
+		// 只发送最接近的一档，更早的档位一并标记为已发送
+		if daysLeft <= nextOffset(reminderOffsets(cfg), offset) {
+			continue
+		}
+
+		log.Printf("自有域名 %s 将在 %d 天后到期", status.Domain, daysLeft)
+		notifications = append(notifications, notifier.DomainNotification{
+			Domain: status.Domain,
+			Status: fmt.Sprintf("将于 %d 天后到期（%s），请及时续费", daysLeft, state.Expiry.Format("2006-01-02")),
+		})
+	}
+
+	return notifications
+}
+
+// nextOffset 返回比 offset 更小的下一档提醒天数，没有时返回 -1
+func nextOffset(offsets []int, offset int) int {
+	for _, o := range offsets {
+		if o < offset {
+			return o
+		}
+	}
+	return -1
+}
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckExpiryReminder(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	reminderStates = nil
+	defer func() { reminderStates = nil }()
+
+	cfg := &config.Config{ExpiryReminderDays: "60,30,7,1"}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiry := start.AddDate(0, 0, 25)
+	renewed := expiry.AddDate(1, 0, 0)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		status  DomainStatus
+		restart bool   // 模拟重启，清空内存中的记录
+		want    string // 期望的通知内容片段，空表示不通知
+		urgent  bool
+	}{
+		{"25 天后到期只发送 30 天档", start, DomainStatus{Registered: true, ExpirationDate: expiry}, false, "25 天后到期", false},
+		{"同一档不重复发送", start.Add(time.Hour), DomainStatus{Registered: true, ExpirationDate: expiry}, false, "", false},
+		{"重启后不重复发送", start.Add(2 * time.Hour), DomainStatus{Registered: true, ExpirationDate: expiry}, true, "", false},
+		{"进入下一档", expiry.AddDate(0, 0, -6), DomainStatus{Registered: true, ExpirationDate: expiry}, false, "6 天后到期", false},
+		{"最后一档", expiry.AddDate(0, 0, -1), DomainStatus{Registered: true, ExpirationDate: expiry}, false, "1 天后到期", false},
+		{"进入自动续费宽限期时升级", expiry.AddDate(0, 0, 1), DomainStatus{Registered: true, ExpirationDate: expiry, AutoRenew: true}, false, "自动续费宽限期", true},
+		{"升级提醒只发送一次", expiry.AddDate(0, 0, 2), DomainStatus{Registered: true, ExpirationDate: expiry, AutoRenew: true}, true, "", false},
+		{"进入赎回期时再次升级", expiry.AddDate(0, 0, 30), DomainStatus{Registered: true, ExpirationDate: expiry, Redemption: true}, false, "赎回期", true},
+		{"续费后不再提醒", expiry.AddDate(0, 0, 31), DomainStatus{Registered: true, ExpirationDate: renewed}, false, "", false},
+		{"续费后进入新周期", renewed.AddDate(0, 0, -50), DomainStatus{Registered: true, ExpirationDate: renewed}, true, "50 天后到期", false},
+	}
+
+	for _, tt := range tests {
+		if tt.restart {
+			reminderStates = nil
+		}
+		tt.status.Domain = "example.com"
+		got := checkExpiryReminder(&tt.status, cfg, tt.now)
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("%s: 不应提醒，实际 %+v", tt.name, got)
+			}
+			continue
+		}
+		if len(got) != 1 || !strings.Contains(got[0].Status, tt.want) || got[0].Urgent != tt.urgent {
+			t.Errorf("%s: 提醒 %+v，期望包含 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReminderOffsets(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{"", []int{60, 30, 7, 1}},
+		{"1, 30,x,-2,7", []int{30, 7, 1}},
+	}
+	for _, tt := range tests {
+		got := reminderOffsets(&config.Config{ExpiryReminderDays: tt.spec})
+		if len(got) != len(tt.want) {
+			t.Errorf("reminderOffsets(%q) = %v", tt.spec, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("reminderOffsets(%q) = %v", tt.spec, got)
+				break
+			}
+		}
+	}
+}
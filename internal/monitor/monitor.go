@@ -1,20 +1,26 @@
 package monitor
 
 import (
+	"Puff/internal/backorder"
 	"Puff/internal/config"
+	"Puff/internal/dnscheck"
 	"Puff/internal/notifier"
 	"Puff/internal/whois"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
 type DomainStatus struct {
 	Domain            string
+	Punycode          string // 国际化域名的 A-label 形式
 	Registered        bool
 	Redemption        bool
 	PendingDelete     bool
+	AutoRenew         bool
+	Owned             bool
 	ExpirationDate    time.Time
 	LastChecked       time.Time
 	FirstNotifiedAt   time.Time
@@ -22,12 +28,51 @@ type DomainStatus struct {
 	NeedsNotification bool
 	IsFinalNotice     bool
 	FinalNoticed      bool // 添加这个字段
+	StatusSince       time.Time
+	PredictedDropDate time.Time
+	ErrorCount        int
+	LastError         string
+	Mode              string
+	LastChange        time.Time // 最近一次检测到 Whois 关键字段变化的时间
+	LastWhois         time.Time // 最近一次 Whois 查询成功的时间，LastChecked 也包含仅查询 DNS 的轮次
+	DNSResult         string    // delegated、nxdomain、undelegated 或 unknown，未启用 DNS 预检时为空
+	DNSChecked        time.Time
+	WhoisSkipped      int // 因 DNS 已委派而连续跳过 Whois 查询的轮数
+	// Evidence 是最近一次 Whois 判断为可注册时各来源的判断
+	Evidence             []string
+	PendingConfirmation  bool // Whois 显示可注册但尚未得到确认
+	AvailableStreak      int
+	AvailableStreakStart time.Time
+	Premium              bool   // 注册商 API 标记为溢价域名
+	Price                string // 注册商 API 报出的注册价格
+	DropCatching         bool   // 正在预计删除时间窗口内高频轮询
+	Restriction          string // 保留、溢价、屏蔽等受限状态，见 whois.Restrictions
 }
 
+// StateChange 记录一次域名状态变化
+type StateChange struct {
+	Domain string
+	From   string
+	To     string
+	At     time.Time
+}
+
+// CheckError 记录一次域名检查失败
+type CheckError struct {
+	Domain string
+	Error  string
+	At     time.Time
+}
+
+// 历史记录保留的最大条数
+const maxHistory = 5000
+
 var (
 	domainStatuses   = make(map[string]*DomainStatus)
 	statusMutex      sync.RWMutex
 	availableDomains []string
+	stateChanges     []StateChange
+	checkErrors      []CheckError
 )
 
 var (
@@ -88,27 +133,34 @@ func updateDomainStatus(domain string, registered bool) {
 		availableDomains = append(availableDomains, domain)
 	}
 }
-func StartMonitoring(whoisServers map[string]string, cfg *config.Config) {
+func StartMonitoring(whoisServers map[string]config.ServerList, cfg *config.Config) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	stopChan = make(chan struct{})
-	wg.Add(1)
+	// 保存设置后会再次调用，先停止正在运行的监控，避免重复的检查循环和抢注轮询
+	stopLocked()
+	stop := make(chan struct{})
+	stopChan = stop
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		runDropCatch(whoisServers, cfg, stop)
+	}()
 
 	go func() {
 		defer wg.Done()
-		ticker := time.NewTicker(time.Duration(cfg.QueryFrequencySeconds) * time.Second)
-		defer ticker.Stop()
 
 		// 立即执行一次检查
-		performCheck(whoisServers, cfg)
+		ticker := time.NewTicker(performCheck(whoisServers, cfg))
+		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
 				log.Println("定时器触发。开始检查域名。")
-				performCheck(whoisServers, cfg)
-			case <-stopChan:
+				ticker.Reset(performCheck(whoisServers, cfg))
+			case <-stop:
 				log.Println("收到停止信号，域名监控退出")
 				return
 			}
@@ -118,27 +170,74 @@ func StartMonitoring(whoisServers map[string]string, cfg *config.Config) {
 	log.Println("域名监控已启动并运行中")
 }
 
-func performCheck(whoisServers map[string]string, cfg *config.Config) {
+// performCheck 检查所有到期需要检查的域名，并返回距下一次检查的间隔
+func performCheck(whoisServers map[string]config.ServerList, cfg *config.Config) time.Duration {
 	startTime := time.Now()
 	log.Printf("开始域名检查，时间：%s", startTime.Format("2006-01-02 15:04:05"))
 
-	domains, err := config.LoadDomainList()
+	entries, err := config.LoadDomainEntries()
 	if err != nil {
 		log.Printf("加载域名列表失败: %v", err)
-		return
+		return checkInterval(config.DomainEntry{}, cfg)
 	}
 
-	RefreshAllDomains(domains, whoisServers, cfg)
+	refreshDomains(dueEntries(entries, cfg, startTime), whoisServers, cfg, true)
 
 	endTime := time.Now()
 	duration := endTime.Sub(startTime)
 	log.Printf("域名检查完成，时间：%s，耗时：%v", endTime.Format("2006-01-02 15:04:05"), duration)
+
+	return nextTick(entries, cfg)
+}
+
+// checkInterval 返回域名的检查间隔，未单独设置时使用全局查询频率
+func checkInterval(entry config.DomainEntry, cfg *config.Config) time.Duration {
+	if entry.CheckInterval > 0 {
+		return time.Duration(entry.CheckInterval) * time.Second
+	}
+	return time.Duration(cfg.QueryFrequencySeconds) * time.Second
+}
+
+// nextTick 返回所有域名中最短的检查间隔
+func nextTick(entries []config.DomainEntry, cfg *config.Config) time.Duration {
+	tick := checkInterval(config.DomainEntry{}, cfg)
+	for _, e := range entries {
+		if interval := checkInterval(e, cfg); interval < tick {
+			tick = interval
+		}
+	}
+	return tick
+}
+
+// dueEntries 过滤出已到检查时间的域名
+func dueEntries(entries []config.DomainEntry, cfg *config.Config, now time.Time) []config.DomainEntry {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	// 允许少量误差，避免因定时器抖动而错过一轮检查
+	const slack = 5 * time.Second
+
+	var due []config.DomainEntry
+	for _, e := range entries {
+		if isCatching(e.Name) {
+			continue
+		}
+		status, exists := domainStatuses[e.Name]
+		if !exists || status.LastChecked.IsZero() || now.Sub(status.LastChecked)+slack >= checkInterval(e, cfg) {
+			due = append(due, e)
+		}
+	}
+	return due
 }
 
 func StopMonitoring() {
 	mu.Lock()
 	defer mu.Unlock()
+	stopLocked()
+}
 
+// stopLocked 停止正在运行的监控并等待退出；调用方需持有 mu
+func stopLocked() {
 	if stopChan != nil {
 		close(stopChan)
 		wg.Wait()
@@ -150,13 +249,17 @@ func checkAllDomains(cfg *config.Config) {
 	log.Printf("开始域名检查，时间：%s", startTime.Format("2006-01-02 15:04:05"))
 
 	// 重新加载域名列表
-	domains, err := config.LoadDomainList()
+	entries, err := config.LoadDomainEntries()
 	if err != nil {
 		log.Printf("加载域名列表时出错：%v", err)
 		return
 	}
 
 	// 更新监控系统中的域名列表
+	domains := make([]string, 0, len(entries))
+	for _, e := range entries {
+		domains = append(domains, e.Name)
+	}
 	UpdateDomainList(domains)
 
 	whoisServers, err := config.LoadWhoisServers()
@@ -166,8 +269,8 @@ func checkAllDomains(cfg *config.Config) {
 	}
 
 	// 使用 whoisServers 检查所有域名
-	for _, domain := range domains {
-		checkDomain(domain, whoisServers, cfg)
+	for _, entry := range entries {
+		checkDomain(entry, whoisServers, cfg)
 	}
 
 	var notifications []notifier.DomainNotification
@@ -184,7 +287,7 @@ func checkAllDomains(cfg *config.Config) {
 
 	if len(notifications) > 0 {
 		log.Printf("发现 %d 个需要通知的域名。准备发送通知。", len(notifications))
-		if err := notifier.SendNotification(notifications, cfg); err != nil {
+		if err := notifier.Dispatch(notifications, cfg); err != nil {
 			log.Printf("发送通知时出错：%v", err)
 		} else {
 			log.Printf("已发送通知")
@@ -199,28 +302,44 @@ func checkAllDomains(cfg *config.Config) {
 	log.Printf("域名检查完成，时间：%s，耗时：%v", endTime.Format("2006-01-02 15:04:05"), duration)
 }
 
-func RefreshAllDomains(domains []string, whoisServers map[string]string, cfg *config.Config) {
+// RefreshAllDomains 立即查询所有域名，不会因 DNS 预检而跳过 Whois 查询
+func RefreshAllDomains(entries []config.DomainEntry, whoisServers map[string]config.ServerList, cfg *config.Config) {
+	refreshDomains(entries, whoisServers, cfg, false)
+}
+
+func refreshDomains(entries []config.DomainEntry, whoisServers map[string]config.ServerList, cfg *config.Config, allowSkip bool) {
 	var wg sync.WaitGroup
-	results := make(chan whois.DomainStatus, len(domains))
+	results := make(chan checkResult, len(entries))
+	entryMap := make(map[string]config.DomainEntry, len(entries))
 
-	for _, domain := range domains {
+	for _, entry := range entries {
+		entryMap[entry.Name] = entry
 		wg.Add(1)
-		go func(d string) {
+		go func(e config.DomainEntry) {
 			defer wg.Done()
 			statusMutex.RLock()
-			status, exists := domainStatuses[d]
+			status, exists := domainStatuses[e.Name]
 			if exists && status.FinalNoticed { // 使用 FinalNoticed 而不是 FinalNotice
 				statusMutex.RUnlock()
 				return
 			}
 			statusMutex.RUnlock()
-			result, err := checkDomain(d, whoisServers, cfg)
+			if cfg.DNSPrefilter && dnsPrecheck(e, cfg, allowSkip) {
+				return
+			}
+			whoisStatus, err := checkDomain(e, whoisServers, cfg)
 			if err != nil {
-				log.Printf("检查域名 %s 错误: %v", d, err)
+				log.Printf("检查域名 %s 错误: %v", e.Name, err)
+				recordCheckError(e.Name, err)
 				return
 			}
+			result := checkResult{DomainStatus: whoisStatus}
+			if alertable(e, whoisStatus) {
+				servers, _ := resolveServer(e, whoisServers)
+				result.Evidence = collectEvidence(e, whoisStatus, servers, cfg)
+			}
 			results <- result
-		}(domain)
+		}(entry)
 	}
 
 	go func() {
@@ -228,7 +347,7 @@ func RefreshAllDomains(domains []string, whoisServers map[string]string, cfg *co
 		close(results)
 	}()
 
-	processResults(results, cfg)
+	processResults(results, entryMap, cfg)
 }
 
 type DomainCheckResult struct {
@@ -237,10 +356,13 @@ type DomainCheckResult struct {
 	Error      error
 }
 
-func processResults(results <-chan whois.DomainStatus, cfg *config.Config) {
+func processResults(results <-chan checkResult, entries map[string]config.DomainEntry, cfg *config.Config) {
 	var notifications []notifier.DomainNotification
 
 	for result := range results {
+		entry := entries[result.Domain]
+		changes := archiveResponse(result.DomainStatus, cfg, time.Now())
+
 		statusMutex.Lock()
 		status, exists := domainStatuses[result.Domain]
 		if !exists {
@@ -250,18 +372,59 @@ func processResults(results <-chan whois.DomainStatus, cfg *config.Config) {
 
 		prevStatus := *status // 保存之前的状态
 
+		// 可注册的结果需要按确认策略核实，未确认前保持原有状态
+		status.Evidence = formatEvidence(result.Evidence)
+		status.PendingConfirmation = false
+		wanted := alertable(entry, result.DomainStatus)
+		if wanted {
+			confirmed, reason := confirmAvailable(status, result.Evidence, cfg, time.Now())
+			if !confirmed {
+				log.Printf("域名 %s 的 Whois 显示可注册，但尚未确认: %s", status.Domain, reason)
+				status.PendingConfirmation = true
+				status.LastChecked = time.Now()
+				statusMutex.Unlock()
+				continue
+			}
+			log.Printf("域名 %s 可注册已确认: %s", status.Domain, reason)
+		} else {
+			status.AvailableStreak = 0
+		}
+
 		status.Registered = result.Registered
+		status.Punycode = entry.Punycode
 		status.Redemption = result.Redemption
 		status.PendingDelete = result.PendingDelete
+		status.AutoRenew = result.AutoRenew
+		status.Owned = entry.IsOwned()
+		status.Mode = entry.Mode
 		status.ExpirationDate = result.ExpirationDate
+		status.Premium = result.Premium
+		status.Price = result.Price
+		status.Restriction = result.Restriction
 		status.LastChecked = time.Now()
+		status.LastWhois = status.LastChecked
+		status.WhoisSkipped = 0
+		status.LastError = ""
+
+		if wanted && status.DNSResult == string(dnscheck.Delegated) {
+			log.Printf("域名 %s 的 Whois 显示可注册，但 DNS 仍有委派，请核实", status.Domain)
+		}
 
 		// 检查状态变化
 		statusChanged := (prevStatus.Registered != status.Registered) ||
 			(prevStatus.Redemption != status.Redemption) ||
-			(prevStatus.PendingDelete != status.PendingDelete)
+			(prevStatus.PendingDelete != status.PendingDelete) ||
+			(prevStatus.Restriction != status.Restriction)
+
+		if prevStatus.LastChecked.IsZero() {
+			status.StatusSince = status.LastChecked
+		} else if statusChanged {
+			status.StatusSince = status.LastChecked
+			recordStateChange(status.Domain, getDomainStatusString(&prevStatus), getDomainStatusString(status), status.LastChecked)
+		}
+		status.PredictedDropDate = predictDropDate(status)
 
-		if !status.Registered {
+		if wanted {
 			if status.FirstNotifiedAt.IsZero() {
 				// 首次检测到未注册状态
 				status.FirstNotifiedAt = time.Now()
@@ -280,6 +443,11 @@ func processResults(results <-chan whois.DomainStatus, cfg *config.Config) {
 					status.NeedsNotification = false
 				}
 			}
+
+			if entry.AutoRegister != nil && entry.AutoRegister.Enabled {
+				quote := backorder.Quote{Price: status.Price, Premium: status.Premium}
+				go autoRegister(entry, quote, status.FirstNotifiedAt, cfg)
+			}
 		} else if status.Redemption || status.PendingDelete {
 			if statusChanged || status.FirstNotifiedAt.IsZero() {
 				// 状态变为赎回期或待删除，或首次检测到这些状态
@@ -290,7 +458,7 @@ func processResults(results <-chan whois.DomainStatus, cfg *config.Config) {
 				status.NeedsNotification = false
 			}
 		} else {
-			// 域名恢复为正常注册状态，重置所有标志
+			// 域名恢复为正常注册状态，或处于不需要通知的受限状态，重置所有标志
 			status.NeedsNotification = false
 			status.IsFinalNotice = false
 			status.FirstNotifiedAt = time.Time{}
@@ -298,35 +466,77 @@ func processResults(results <-chan whois.DomainStatus, cfg *config.Config) {
 			status.FinalNoticed = false
 		}
 
+		var pending []notifier.DomainNotification
 		if status.NeedsNotification {
-			notifications = append(notifications, notifier.DomainNotification{
-				Domain:        status.Domain,
-				IsFinalNotice: status.IsFinalNotice,
-				Status:        getDomainStatusString(status),
+			pending = append(pending, statusNotification(entry, status))
+		}
+
+		if status.Owned {
+			pending = append(pending, checkExpiryReminder(status, cfg, status.LastChecked)...)
+		}
+
+		// 变更监测模式的域名总是发送字段变化，其他域名取决于全局设置
+		if len(changes) > 0 && (entry.DetectsChanges() || cfg.NotifyFieldChanges) {
+			status.LastChange = status.LastChecked
+			pending = append(pending, notifier.DomainNotification{
+				Domain:  status.Domain,
+				Status:  "Whois 信息变化",
+				Changes: whois.FormatChanges(changes),
 			})
 		}
 
+		// 预期状态检查独立于可注册通知，偏离时总是发送
+		pending = append(pending, checkExpectedState(entry, result.DomainStatus)...)
+
+		previous := "未查询"
+		if !prevStatus.LastChecked.IsZero() {
+			previous = getDomainStatusString(&prevStatus)
+		}
+		for _, n := range pending {
+			n.Channels = entry.Channels
+			n.Priority = max(n.Priority, entry.Priority)
+			n.Tags = entry.Tags
+			n.PreviousStatus = previous
+			notifications = append(notifications, n)
+		}
+
 		statusMutex.Unlock()
 	}
 
-	// 发送通知的代码保持不变
+	// 优先级高的域名排在前面
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].Priority > notifications[j].Priority
+	})
+
 	if len(notifications) > 0 {
-		if err := notifier.SendNotification(notifications, cfg); err != nil {
-			log.Printf("发送邮件错误: %v", err)
+		if err := notifier.Dispatch(notifications, cfg); err != nil {
+			log.Printf("发送通知错误: %v", err)
 		} else {
 			resetNotificationFlags(notifications)
 		}
 	}
 }
 
-func checkDomain(domain string, whoisServers map[string]string, cfg *config.Config) (whois.DomainStatus, error) {
-	tld := whois.GetTLD(domain)
-	whoisServer, ok := whoisServers[tld]
-	if !ok {
-		return whois.DomainStatus{}, fmt.Errorf("未找到 %s 的Whois服务器", tld)
+// statusNotification 生成域名状态通知。可注册的最终通知和抢注域名的可注册通知为紧急通知，
+// 不受免打扰和限流约束：抢注域名通常在凌晨删除，推迟到早上就失去了意义
+func statusNotification(entry config.DomainEntry, status *DomainStatus) notifier.DomainNotification {
+	return notifier.DomainNotification{
+		Domain:        status.Domain,
+		IsFinalNotice: status.IsFinalNotice,
+		Status:        getDomainStatusString(status),
+		Urgent:        !status.Registered && (status.IsFinalNotice || entry.DropCatch),
+		Evidence:      status.Evidence,
+	}
+}
+
+func checkDomain(entry config.DomainEntry, whoisServers map[string]config.ServerList, cfg *config.Config) (whois.DomainStatus, error) {
+	domain := entry.Name
+	servers, err := resolveServer(entry, whoisServers)
+	if err != nil {
+		return whois.DomainStatus{}, err
 	}
 
-	status, err := whois.QueryDomain(domain, whoisServer)
+	status, err := whois.Query(domain, servers)
 	if err != nil {
 		log.Printf("查询域名 %s 时出错：%v", domain, err)
 		return whois.DomainStatus{}, err
@@ -336,8 +546,37 @@ func checkDomain(domain string, whoisServers map[string]string, cfg *config.Conf
 	return status, nil
 }
 
+// resolveServer 返回域名使用的服务器列表，域名单独配置的服务器排在最前面，其后是后缀的服务器
+func resolveServer(entry config.DomainEntry, whoisServers map[string]config.ServerList) ([]config.WhoisServer, error) {
+	var servers []config.WhoisServer
+	if entry.WhoisServer != "" {
+		servers = append(servers, config.WhoisServer{Host: entry.WhoisServer})
+	}
+	tldServers, _ := whois.FindServer(whoisServers, entry.Name)
+	for _, server := range tldServers {
+		if server.Address() != entry.WhoisServer {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("未找到 %s 的Whois服务器", whois.GetTLD(entry.Name))
+	}
+	return servers, nil
+}
+
+// LookupDomain 按与监控相同的方式选择服务器并查询域名，返回原始响应和转介链，不影响监控状态
+func LookupDomain(entry config.DomainEntry, whoisServers map[string]config.ServerList) (*whois.LookupResult, error) {
+	servers, err := resolveServer(entry, whoisServers)
+	if err != nil {
+		return nil, err
+	}
+	return whois.Lookup(entry.Name, servers)
+}
+
 func logDomainStatus(domain string, status whois.DomainStatus) {
-	if !status.Registered {
+	if status.Restriction != "" {
+		log.Printf("域名 %s 状态: %s", domain, restrictionText(status.Restriction))
+	} else if !status.Registered {
 		log.Printf("域名 %s 状态: 可注册", domain)
 	} else if status.PendingDelete {
 		log.Printf("域名 %s 状态: 待删除", domain)
@@ -363,6 +602,72 @@ func resetNotificationFlags(notifications []notifier.DomainNotification) {
 	}
 }
 
+// predictDropDate 根据进入赎回期或待删除状态的时间推算域名释放日期
+func predictDropDate(status *DomainStatus) time.Time {
+	switch {
+	case !status.Registered:
+		return time.Time{}
+	case status.PendingDelete:
+		// 待删除期通常为 5 天
+		return status.StatusSince.AddDate(0, 0, 5)
+	case status.Redemption:
+		// 赎回期通常为 30 天，之后进入 5 天的待删除期
+		return status.StatusSince.AddDate(0, 0, 35)
+	}
+	return time.Time{}
+}
+
+func recordStateChange(domain, from, to string, at time.Time) {
+	stateChanges = append(stateChanges, StateChange{Domain: domain, From: from, To: to, At: at})
+	if len(stateChanges) > maxHistory {
+		stateChanges = stateChanges[len(stateChanges)-maxHistory:]
+	}
+}
+
+func recordCheckError(domain string, err error) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	now := time.Now()
+	if status, exists := domainStatuses[domain]; exists {
+		status.ErrorCount++
+		status.LastError = err.Error()
+	}
+
+	checkErrors = append(checkErrors, CheckError{Domain: domain, Error: err.Error(), At: now})
+	if len(checkErrors) > maxHistory {
+		checkErrors = checkErrors[len(checkErrors)-maxHistory:]
+	}
+}
+
+// GetStateChanges 返回 since 之后发生的状态变化
+func GetStateChanges(since time.Time) []StateChange {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	var changes []StateChange
+	for _, c := range stateChanges {
+		if c.At.After(since) {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// GetCheckErrors 返回 since 之后发生的检查错误
+func GetCheckErrors(since time.Time) []CheckError {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	var errs []CheckError
+	for _, e := range checkErrors {
+		if e.At.After(since) {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
 func GetDomainStatuses() []DomainStatus {
 	statusMutex.RLock()
 	defer statusMutex.RUnlock()
@@ -373,6 +678,17 @@ func GetDomainStatuses() []DomainStatus {
 	return statuses
 }
 
+// GetDomainStatus 返回单个域名的监控状态
+func GetDomainStatus(domain string) (DomainStatus, bool) {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+	status, exists := domainStatuses[domain]
+	if !exists {
+		return DomainStatus{}, false
+	}
+	return *status, true
+}
+
 func UpdateDomainList(domains []string) {
 	statusMutex.Lock()
 	defer statusMutex.Unlock()
@@ -381,6 +697,8 @@ func UpdateDomainList(domains []string) {
 	for domain := range domainStatuses {
 		if !contains(domains, domain) {
 			delete(domainStatuses, domain)
+			delete(reminderStates, domain)
+			delete(securityStates, domain)
 		}
 	}
 
@@ -401,8 +719,15 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// GetDomainStatusString 返回域名状态的中文描述
+func GetDomainStatusString(status *DomainStatus) string {
+	return getDomainStatusString(status)
+}
+
 func getDomainStatusString(status *DomainStatus) string {
-	if !status.Registered {
+	if status.Restriction != "" {
+		return restrictionText(status.Restriction)
+	} else if !status.Registered {
 		return "可注册"
 	} else if status.PendingDelete {
 		return "待删除"
@@ -412,3 +737,23 @@ func getDomainStatusString(status *DomainStatus) string {
 		return "已注册"
 	}
 }
+
+// restrictionText 返回受限状态的显示名称
+func restrictionText(restriction string) string {
+	switch restriction {
+	case whois.RestrictionReserved:
+		return "保留"
+	case whois.RestrictionPremium:
+		return "溢价"
+	case whois.RestrictionBlocked:
+		return "屏蔽"
+	case whois.RestrictionInvalid:
+		return "无效"
+	}
+	return restriction
+}
+
+// alertable 判断查询结果是否应按可注册处理：未注册，且不处于受限状态或域名配置了接收该状态
+func alertable(entry config.DomainEntry, status whois.DomainStatus) bool {
+	return !status.Registered && (status.Restriction == "" || entry.WantsRestriction(status.Restriction))
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/notifier"
+	"Puff/internal/whois"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// 安全告警的优先级，高于普通域名设置的优先级
+const securityPriority = 1000
+
+// 上一次检测到的偏离，用于避免同一问题重复告警
+var securityStates = make(map[string]string)
+
+// expectedStateDeviations 比较实际的 Whois 记录与预期状态，返回所有偏离项
+func expectedStateDeviations(expected *config.ExpectedState, fields whois.Fields) []string {
+	var deviations []string
+
+	if expected.Registrar != "" &&
+		!strings.Contains(strings.ToLower(fields.Registrar), strings.ToLower(expected.Registrar)) {
+		deviations = append(deviations, fmt.Sprintf("注册商变为 %s，预期为 %s", orUnknown(fields.Registrar), expected.Registrar))
+	}
+
+	if len(expected.NameServers) > 0 {
+		want := append([]string{}, expected.NameServers...)
+		sort.Strings(want)
+		if strings.Join(want, ",") != strings.Join(fields.NameServers, ",") {
+			deviations = append(deviations, fmt.Sprintf("域名服务器变为 %s，预期为 %s",
+				orUnknown(strings.Join(fields.NameServers, ", ")), strings.Join(want, ", ")))
+		}
+	}
+
+	for _, lock := range expected.Locks {
+		found := false
+		for _, s := range fields.Statuses {
+			if strings.EqualFold(s, lock) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			deviations = append(deviations, fmt.Sprintf("缺少锁定状态 %s", lock))
+		}
+	}
+
+	return deviations
+}
+
+// checkExpectedState 在实际记录偏离预期时生成高优先级告警，恢复正常时发送恢复通知。
+// 调用方需持有 statusMutex。
+func checkExpectedState(entry config.DomainEntry, result whois.DomainStatus) []notifier.DomainNotification {
+	if entry.Expected.IsEmpty() || !result.Registered {
+		delete(securityStates, entry.Name)
+		return nil
+	}
+	// 切换到注册商 API 或 HTTP 查询时无法比较，保留上一次的结果，避免误报和反复恢复
+	if !result.HasRecord() {
+		return nil
+	}
+
+	deviations := expectedStateDeviations(entry.Expected, whois.ParseFields(result.Raw))
+	signature := strings.Join(deviations, "\n")
+	previous := securityStates[entry.Name]
+	if signature == previous {
+		return nil
+	}
+	securityStates[entry.Name] = signature
+
+	if len(deviations) == 0 {
+		log.Printf("域名 %s 已恢复为预期状态", entry.Name)
+		return []notifier.DomainNotification{{
+			Domain:   entry.Name,
+			Status:   "已恢复为预期状态",
+			Priority: securityPriority,
+		}}
+	}
+
+	log.Printf("域名 %s 偏离预期状态: %s", entry.Name, strings.Join(deviations, "；"))
+	return []notifier.DomainNotification{{
+		Domain:   entry.Name,
+		Status:   "安全告警：Whois 记录偏离预期状态",
+		Urgent:   true,
+		Priority: securityPriority,
+		Changes:  deviations,
+	}}
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "未知"
+	}
+	return s
+}
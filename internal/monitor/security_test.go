@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/whois"
+	"reflect"
+	"testing"
+)
+
+func TestExpectedStateDeviations(t *testing.T) {
+	expected := &config.ExpectedState{
+		Registrar:   "Example Registrar",
+		NameServers: []string{"ns2.example.net", "ns1.example.net"},
+		Locks:       []string{"clientTransferProhibited"},
+	}
+
+	tests := []struct {
+		name   string
+		fields whois.Fields
+		want   []string
+	}{
+		{
+			name: "一致",
+			fields: whois.Fields{
+				Registrar:   "EXAMPLE REGISTRAR, INC.",
+				NameServers: []string{"ns1.example.net", "ns2.example.net"},
+				Statuses:    []string{"ClientTransferProhibited", "clientDeleteProhibited"},
+			},
+		},
+		{
+			name: "注册商、域名服务器和锁定状态都偏离",
+			fields: whois.Fields{
+				Registrar:   "Other Registrar",
+				NameServers: []string{"ns1.attacker.net"},
+			},
+			want: []string{
+				"注册商变为 Other Registrar，预期为 Example Registrar",
+				"域名服务器变为 ns1.attacker.net，预期为 ns1.example.net, ns2.example.net",
+				"缺少锁定状态 clientTransferProhibited",
+			},
+		},
+		{
+			name: "记录缺少字段",
+			want: []string{
+				"注册商变为 未知，预期为 Example Registrar",
+				"域名服务器变为 未知，预期为 ns1.example.net, ns2.example.net",
+				"缺少锁定状态 clientTransferProhibited",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := expectedStateDeviations(expected, tt.fields); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %q", tt.name, got)
+		}
+	}
+}
+
+func TestCheckExpectedStateSkipsOtherSources(t *testing.T) {
+	securityStates = make(map[string]string)
+	entry := config.DomainEntry{Name: "example.com", Expected: &config.ExpectedState{NameServers: []string{"ns1.example.net"}}}
+	record := "Domain Name: example.com\nName Server: ns1.example.net\n"
+	moved := "Domain Name: example.com\nName Server: ns1.attacker.net\n"
+
+	steps := []struct {
+		name   string
+		result whois.DomainStatus
+		want   string // 期望的通知状态，空表示不通知
+	}{
+		{"Whois 记录一致", whois.DomainStatus{Registered: true, Source: whois.SourceWhois, Raw: record}, ""},
+		{"注册商 API 的响应不比较", whois.DomainStatus{Registered: true, Source: whois.SourceRegistrar, Raw: `{"available": false}`}, ""},
+		{"HTTP 查询的响应不比较", whois.DomainStatus{Registered: true, Source: whois.SourceHTTP, Raw: "taken"}, ""},
+		{"跳过 Whois 查询时不比较", whois.DomainStatus{Registered: true}, ""},
+		{"RDAP 记录一致", whois.DomainStatus{Registered: true, Source: whois.SourceRDAP, Raw: record}, ""},
+		{"Whois 记录偏离", whois.DomainStatus{Registered: true, Source: whois.SourceWhois, Raw: moved}, "安全告警：Whois 记录偏离预期状态"},
+		{"切换来源时不会误报恢复", whois.DomainStatus{Registered: true, Source: whois.SourceRegistrar, Raw: `{"available": false}`}, ""},
+		{"偏离未变化时不重复告警", whois.DomainStatus{Registered: true, Source: whois.SourceWhois, Raw: moved}, ""},
+		{"恢复", whois.DomainStatus{Registered: true, Source: whois.SourceWhois, Raw: record}, "已恢复为预期状态"},
+	}
+	for _, s := range steps {
+		got := checkExpectedState(entry, s.result)
+		switch {
+		case s.want == "" && len(got) != 0:
+			t.Errorf("%s: 不应通知，实际 %+v", s.name, got)
+		case s.want != "" && (len(got) != 1 || got[0].Status != s.want):
+			t.Errorf("%s: 通知 %+v，期望 %s", s.name, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package notifier
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Channel 是一个通知渠道
+type Channel interface {
+	Name() string
+	Send(m Message, cfg *config.Config) error
+}
+
+type emailChannel struct{}
+
+func (emailChannel) Name() string { return "email" }
+
+func (emailChannel) Send(m Message, cfg *config.Config) error {
+	return SendMessage(m, cfg)
+}
+
+// Channels 返回所有可用的通知渠道
+func Channels() []Channel {
+	return []Channel{emailChannel{}}
+}
+
+// ChannelPolicy 描述单个渠道的免打扰与限流设置
+type ChannelPolicy struct {
+	QuietHours string // 形如 23:00-08:00，留空表示不启用
+	MaxPerHour int    // 每小时最多发送的消息数，0 表示不限制
+}
+
+func channelPolicy(name string, cfg *config.Config) ChannelPolicy {
+	switch name {
+	case "email":
+		return ChannelPolicy{QuietHours: cfg.EmailQuietHours, MaxPerHour: cfg.EmailMaxPerHour}
+	}
+	return ChannelPolicy{}
+}
+
+type channelState struct {
+	sent    []time.Time
+	pending []DomainNotification
+}
+
+var (
+	dispatchMutex sync.Mutex
+	channelStates = make(map[string]*channelState)
+	// 去重记录：渠道+域名+状态 -> 最近一次发送时间
+	recentlySent = make(map[string]time.Time)
+)
+
+func stateFor(name string) *channelState {
+	state, exists := channelStates[name]
+	if !exists {
+		state = &channelState{}
+		channelStates[name] = state
+	}
+	return state
+}
+
+func dedupeKey(channel string, n DomainNotification) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%t|%s", channel, n.Domain, n.Status, strings.Join(n.Changes, ";"), n.IsFinalNotice, recipientsKey(n))
+}
+
+func recipientsKey(n DomainNotification) string {
+	return strings.Join(n.Recipients, ",")
+}
+
+// Dispatch 按各渠道的去重、免打扰和限流策略发送域名通知。
+// 被推迟或合并的通知会在 StartDispatcher 的定时任务中补发。
+func Dispatch(notifications []DomainNotification, cfg *config.Config) error {
+	dispatchMutex.Lock()
+	defer dispatchMutex.Unlock()
+
+	rules, err := config.LoadRoutingRules()
+	if err != nil {
+		log.Printf("加载通知路由规则失败，使用默认路由: %v", err)
+		rules = &config.RoutingRules{}
+	}
+
+	var firstErr error
+	for _, ch := range Channels() {
+		routed := expandRoutes(notifications, ch.Name(), rules)
+		if len(routed) == 0 {
+			continue
+		}
+		if err := dispatchTo(ch, routed, cfg, time.Now()); err != nil {
+			log.Printf("渠道 %s 发送通知失败: %v", ch.Name(), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func dispatchTo(ch Channel, notifications []DomainNotification, cfg *config.Config, now time.Time) error {
+	name := ch.Name()
+	policy := channelPolicy(name, cfg)
+	state := stateFor(name)
+	window := time.Duration(cfg.NotifyDedupeMinutes) * time.Minute
+
+	var fresh []DomainNotification
+	for _, n := range notifications {
+		key := dedupeKey(name, n)
+		if last, ok := recentlySent[key]; ok && window > 0 && now.Sub(last) < window {
+			log.Printf("渠道 %s 在去重窗口内已发送过 %s 的%s通知，跳过", name, n.Domain, n.Status)
+			continue
+		}
+		fresh = append(fresh, n)
+	}
+
+	var toSend []DomainNotification
+	if inQuietHours(policy.QuietHours, now, cfg) {
+		for _, n := range fresh {
+			if isUrgent(n, cfg) {
+				toSend = append(toSend, n)
+			} else {
+				state.pending = appendUnique(state.pending, n)
+			}
+		}
+		if len(fresh) > len(toSend) {
+			log.Printf("渠道 %s 处于免打扰时段，已推迟 %d 条通知", name, len(fresh)-len(toSend))
+		}
+	} else {
+		toSend = state.pending
+		state.pending = nil
+		for _, n := range fresh {
+			toSend = appendUnique(toSend, n)
+		}
+	}
+
+	if len(toSend) > 0 && rateExceeded(state, policy, now) {
+		// 只有紧急通知不受限流约束，其余通知留待合并发送
+		var urgent []DomainNotification
+		for _, n := range toSend {
+			if isUrgent(n, cfg) {
+				urgent = append(urgent, n)
+			} else {
+				state.pending = appendUnique(state.pending, n)
+			}
+		}
+		if len(toSend) > len(urgent) {
+			log.Printf("渠道 %s 已达到每小时 %d 条的发送上限，%d 条通知将合并后发送", name, policy.MaxPerHour, len(toSend)-len(urgent))
+		}
+		toSend = urgent
+	}
+
+	if len(toSend) == 0 {
+		return nil
+	}
+
+	// 按收件人分组，每组发送一条消息
+	var order []string
+	groups := make(map[string][]DomainNotification)
+	for _, n := range toSend {
+		key := recipientsKey(n)
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+
+	var firstErr error
+	for _, key := range order {
+		group := groups[key]
+		msg := notificationMessage(group)
+		msg.Recipients = group[0].Recipients
+		if err := ch.Send(msg, cfg); err != nil {
+			// 发送失败的通知放回待发送队列，由定时任务重试，去重和限流记录保持不变
+			for _, n := range group {
+				state.pending = appendUnique(state.pending, n)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		state.sent = append(state.sent, now)
+		for _, n := range group {
+			recentlySent[dedupeKey(name, n)] = now
+		}
+	}
+	return firstErr
+}
+
+func notificationMessage(notifications []DomainNotification) Message {
+	return Message{
+		Subject: "域名状态变更提醒",
+		Text:    generateTextBody(notifications),
+		HTML:    generateEmailBody(notifications),
+	}
+}
+
+func appendUnique(list []DomainNotification, n DomainNotification) []DomainNotification {
+	for i, existing := range list {
+		if existing.Domain == n.Domain && recipientsKey(existing) == recipientsKey(n) &&
+			(len(existing.Changes) > 0) == (len(n.Changes) > 0) {
+			// 同一域名只保留最新的通知，字段变化则累积保留
+			if len(n.Changes) > 0 {
+				n.Changes = append(append([]string{}, existing.Changes...), n.Changes...)
+			}
+			list[i] = n
+			return list
+		}
+	}
+	return append(list, n)
+}
+
+// isUrgent 判断通知是否不受免打扰时段和限流约束
+func isUrgent(n DomainNotification, cfg *config.Config) bool {
+	return n.Urgent && cfg.NotifyUrgentFinal
+}
+
+func rateExceeded(state *channelState, policy ChannelPolicy, now time.Time) bool {
+	// 仅保留最近一小时的发送记录
+	cutoff := now.Add(-time.Hour)
+	kept := state.sent[:0]
+	for _, t := range state.sent {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	state.sent = kept
+
+	return policy.MaxPerHour > 0 && len(state.sent) >= policy.MaxPerHour
+}
+
+// inQuietHours 判断 now 是否落在 "HH:MM-HH:MM" 描述的时段内，支持跨越午夜
+func inQuietHours(spec string, now time.Time, cfg *config.Config) bool {
+	if spec == "" {
+		return false
+	}
+
+	var startH, startM, endH, endM int
+	if _, err := fmt.Sscanf(spec, "%d:%d-%d:%d", &startH, &startM, &endH, &endM); err != nil {
+		log.Printf("无效的免打扰时段 %s: %v", spec, err)
+		return false
+	}
+
+	loc := time.Local
+	if cfg.NotifyTimezone != "" {
+		if l, err := time.LoadLocation(cfg.NotifyTimezone); err == nil {
+			loc = l
+		}
+	}
+
+	local := now.In(loc)
+	current := local.Hour()*60 + local.Minute()
+	start := startH*60 + startM
+	end := endH*60 + endM
+
+	if start <= end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+// flushPending 发送已推迟或被限流的通知
+func flushPending(cfg *config.Config) {
+	dispatchMutex.Lock()
+	defer dispatchMutex.Unlock()
+
+	now := time.Now()
+	for _, ch := range Channels() {
+		state := stateFor(ch.Name())
+		if len(state.pending) == 0 {
+			continue
+		}
+		if err := dispatchTo(ch, nil, cfg, now); err != nil {
+			log.Printf("渠道 %s 补发通知失败: %v", ch.Name(), err)
+		}
+	}
+
+	// 清理过期的去重记录
+	window := time.Duration(cfg.NotifyDedupeMinutes) * time.Minute
+	for key, t := range recentlySent {
+		if now.Sub(t) >= window {
+			delete(recentlySent, key)
+		}
+	}
+}
+
+// Broadcast 不经过免打扰和限流，直接向所有渠道发送消息
+func Broadcast(m Message, cfg *config.Config) error {
+	var firstErr error
+	for _, ch := range Channels() {
+		if err := ch.Send(m, cfg); err != nil {
+			log.Printf("渠道 %s 发送消息失败: %v", ch.Name(), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// StartDispatcher 启动定时任务，每分钟补发被推迟的通知
+func StartDispatcher() {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				log.Printf("加载配置失败: %v", err)
+				continue
+			}
+			flushPending(cfg)
+		}
+	}()
+}
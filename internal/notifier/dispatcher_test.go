@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"Puff/internal/config"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeChannel 记录收到的消息，fail 为 true 时发送失败
+type fakeChannel struct {
+	sent []Message
+	fail bool
+}
+
+func (c *fakeChannel) Name() string { return "email" }
+
+func (c *fakeChannel) Send(m Message, cfg *config.Config) error {
+	if c.fail {
+		return errors.New("smtp 暂时不可用")
+	}
+	c.sent = append(c.sent, m)
+	return nil
+}
+
+func resetDispatcher() {
+	channelStates = make(map[string]*channelState)
+	recentlySent = make(map[string]time.Time)
+}
+
+// at 返回 UTC 时间 2024-01-01 的 hh:mm 加上 days 天
+func at(days, hh, mm int) time.Time {
+	return time.Date(2024, 1, 1+days, hh, mm, 0, 0, time.UTC)
+}
+
+type step struct {
+	now   time.Time
+	send  []DomainNotification
+	fail  bool
+	sent  int // 本步发送的消息数
+	queue int // 本步结束后待发送的通知数
+}
+
+func TestDispatchTo(t *testing.T) {
+	available := DomainNotification{Domain: "example.com", Status: "可注册"}
+	other := DomainNotification{Domain: "example.net", Status: "可注册"}
+	urgent := DomainNotification{Domain: "example.org", Status: "可注册", IsFinalNotice: true, Urgent: true}
+	dropped := DomainNotification{Domain: "example.io", Status: "可注册", Urgent: true} // 抢注域名的首次通知
+
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		steps []step
+	}{
+		{
+			name: "去重窗口内跳过相同通知",
+			cfg:  config.Config{NotifyDedupeMinutes: 30},
+			steps: []step{
+				{now: at(0, 10, 0), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 10, 20), send: []DomainNotification{available}, sent: 0},
+				{now: at(0, 10, 30), send: []DomainNotification{available}, sent: 1},
+			},
+		},
+		{
+			name: "去重窗口为 0 时不去重",
+			cfg:  config.Config{},
+			steps: []step{
+				{now: at(0, 10, 0), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 10, 1), send: []DomainNotification{available}, sent: 1},
+			},
+		},
+		{
+			name: "免打扰时段推迟，结束后补发",
+			cfg:  config.Config{EmailQuietHours: "23:00-08:00", NotifyTimezone: "UTC"},
+			steps: []step{
+				{now: at(0, 23, 30), send: []DomainNotification{available}, sent: 0, queue: 1},
+				{now: at(1, 7, 59), send: []DomainNotification{other}, sent: 0, queue: 2},
+				{now: at(1, 8, 0), sent: 1, queue: 0},
+			},
+		},
+		{
+			name: "免打扰时段不跨越午夜",
+			cfg:  config.Config{EmailQuietHours: "12:00-14:00", NotifyTimezone: "UTC"},
+			steps: []step{
+				{now: at(0, 11, 59), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 12, 0), send: []DomainNotification{other}, sent: 0, queue: 1},
+				{now: at(0, 14, 0), sent: 1},
+			},
+		},
+		{
+			name: "最终通知不受免打扰限制",
+			cfg:  config.Config{EmailQuietHours: "23:00-08:00", NotifyTimezone: "UTC", NotifyUrgentFinal: true},
+			steps: []step{
+				{now: at(0, 23, 30), send: []DomainNotification{available, urgent}, sent: 1, queue: 1},
+			},
+		},
+		{
+			name: "抢注域名的首次可注册通知不受免打扰限制",
+			cfg:  config.Config{EmailQuietHours: "23:00-08:00", NotifyTimezone: "UTC", NotifyUrgentFinal: true, EmailMaxPerHour: 1},
+			steps: []step{
+				{now: at(1, 2, 30), send: []DomainNotification{available}, sent: 0, queue: 1},
+				{now: at(1, 2, 31), send: []DomainNotification{dropped}, sent: 1, queue: 1},
+				{now: at(1, 2, 32), send: []DomainNotification{dropped}, sent: 1, queue: 1},
+			},
+		},
+		{
+			name: "未开启紧急通知时最终通知同样推迟",
+			cfg:  config.Config{EmailQuietHours: "23:00-08:00", NotifyTimezone: "UTC"},
+			steps: []step{
+				{now: at(0, 23, 30), send: []DomainNotification{urgent}, sent: 0, queue: 1},
+			},
+		},
+		{
+			name: "超过每小时上限后合并，一小时后发送",
+			cfg:  config.Config{EmailMaxPerHour: 1},
+			steps: []step{
+				{now: at(0, 10, 0), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 10, 10), send: []DomainNotification{other}, sent: 0, queue: 1},
+				{now: at(0, 10, 20), send: []DomainNotification{urgent}, sent: 0, queue: 2},
+				{now: at(0, 11, 0), sent: 1},
+			},
+		},
+		{
+			name: "紧急通知不受限流约束",
+			cfg:  config.Config{EmailMaxPerHour: 1, NotifyUrgentFinal: true},
+			steps: []step{
+				{now: at(0, 10, 0), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 10, 10), send: []DomainNotification{urgent}, sent: 1},
+			},
+		},
+		{
+			name: "紧急通知不会带着其他待发送通知越过限流",
+			cfg:  config.Config{EmailMaxPerHour: 1, NotifyUrgentFinal: true},
+			steps: []step{
+				{now: at(0, 10, 0), send: []DomainNotification{available}, sent: 1},
+				{now: at(0, 10, 10), send: []DomainNotification{other}, sent: 0, queue: 1},
+				{now: at(0, 10, 20), send: []DomainNotification{urgent, available}, sent: 1, queue: 2},
+				{now: at(0, 11, 0), sent: 0, queue: 2},
+				{now: at(0, 11, 20), sent: 1, queue: 0},
+			},
+		},
+		{
+			name: "发送失败的通知保留并重试",
+			cfg:  config.Config{EmailQuietHours: "23:00-08:00", NotifyTimezone: "UTC", NotifyDedupeMinutes: 30, EmailMaxPerHour: 1},
+			steps: []step{
+				{now: at(0, 23, 30), send: []DomainNotification{available}, sent: 0, queue: 1},
+				{now: at(1, 8, 0), send: []DomainNotification{other}, fail: true, sent: 0, queue: 2},
+				{now: at(1, 8, 1), sent: 1, queue: 0},
+				{now: at(1, 8, 2), send: []DomainNotification{other}, sent: 0, queue: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetDispatcher()
+			ch := &fakeChannel{}
+			for i, s := range tt.steps {
+				before := len(ch.sent)
+				ch.fail = s.fail
+				err := dispatchTo(ch, s.send, &tt.cfg, s.now)
+				if (err != nil) != s.fail {
+					t.Fatalf("第 %d 步: err = %v", i+1, err)
+				}
+				if got := len(ch.sent) - before; got != s.sent {
+					t.Errorf("第 %d 步: 发送 %d 条消息，期望 %d", i+1, got, s.sent)
+				}
+				if got := len(stateFor(ch.Name()).pending); got != s.queue {
+					t.Errorf("第 %d 步: 待发送 %d 条，期望 %d", i+1, got, s.queue)
+				}
+			}
+		})
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	shanghai := config.Config{NotifyTimezone: "Asia/Shanghai"}
+	tests := []struct {
+		spec string
+		now  time.Time
+		cfg  config.Config
+		want bool
+	}{
+		{"", at(0, 23, 30), config.Config{}, false},
+		{"无效", at(0, 23, 30), config.Config{}, false},
+		{"23:00-08:00", at(0, 22, 59), config.Config{NotifyTimezone: "UTC"}, false},
+		{"23:00-08:00", at(0, 23, 0), config.Config{NotifyTimezone: "UTC"}, true},
+		{"23:00-08:00", at(0, 3, 0), config.Config{NotifyTimezone: "UTC"}, true},
+		{"23:00-08:00", at(0, 8, 0), config.Config{NotifyTimezone: "UTC"}, false},
+		{"09:00-17:30", at(0, 17, 29), config.Config{NotifyTimezone: "UTC"}, true},
+		{"09:00-17:30", at(0, 17, 30), config.Config{NotifyTimezone: "UTC"}, false},
+		// UTC 15:30 是上海时间 23:30
+		{"23:00-08:00", at(0, 15, 30), shanghai, true},
+		{"23:00-08:00", at(0, 0, 30), shanghai, false},
+	}
+
+	for _, tt := range tests {
+		if got := inQuietHours(tt.spec, tt.now, &tt.cfg); got != tt.want {
+			t.Errorf("inQuietHours(%q, %s, %s) = %t，期望 %t", tt.spec, tt.now.Format("15:04"), tt.cfg.NotifyTimezone, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package notifier
+
+import (
+	"Puff/internal/config"
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestMailMessageBytes(t *testing.T) {
+	msg := &mailMessage{
+		From:    mail.Address{Name: "Puff 监控", Address: "bot@example.com"},
+		ReplyTo: "ops@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Cc:      []string{"c@example.com"},
+		Subject: "域名 example.com 可注册",
+		Text:    "纯文本正文，包含一行很长的内容" + strings.Repeat("=", 100),
+		HTML:    "<p>网页正文</p>",
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := parsed.Header
+
+	dec := new(mime.WordDecoder)
+	if subject, err := dec.DecodeHeader(h.Get("Subject")); err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if !strings.HasPrefix(h.Get("Subject"), "=?UTF-8?b?") {
+		t.Errorf("Subject 未按 RFC 2047 编码: %q", h.Get("Subject"))
+	}
+	if from, err := mail.ParseAddress(h.Get("From")); err != nil || from.Name != "Puff 监控" || from.Address != "bot@example.com" {
+		t.Errorf("From = %+v, %v", from, err)
+	}
+	if to, err := h.AddressList("To"); err != nil || len(to) != 2 {
+		t.Errorf("To = %v, %v", to, err)
+	}
+	if cc, err := h.AddressList("Cc"); err != nil || len(cc) != 1 || cc[0].Address != "c@example.com" {
+		t.Errorf("Cc = %v, %v", cc, err)
+	}
+	if h.Get("Bcc") != "" {
+		t.Error("邮件头不应包含 Bcc")
+	}
+	if h.Get("Reply-To") != "<ops@example.com>" || h.Get("MIME-Version") != "1.0" {
+		t.Errorf("Reply-To %q，MIME-Version %q", h.Get("Reply-To"), h.Get("MIME-Version"))
+	}
+	if _, err := h.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	if id := h.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", h.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, w := range want {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Content-Type") != w.contentType || part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+			t.Errorf("分段头 %v", part.Header)
+		}
+		raw, _ := io.ReadAll(part)
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			if len(line) > 76 {
+				t.Errorf("quoted-printable 行超过 76 个字符: %q", line)
+			}
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+		if err != nil || string(body) != w.body {
+			t.Errorf("%s 正文 %q, %v", w.contentType, body, err)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("多余的分段: %v", err)
+	}
+}
+
+func TestLoginAuth(t *testing.T) {
+	auth := LoginAuth("user", "secret")
+	proto, initial, err := auth.Start(nil)
+	if proto != "LOGIN" || initial != nil || err != nil {
+		t.Fatalf("Start = %q %q %v", proto, initial, err)
+	}
+
+	tests := []struct {
+		prompt string
+		more   bool
+		want   string
+		err    bool
+	}{
+		{"Username:", true, "user", false},
+		{"  PASSWORD:  ", true, "secret", false},
+		{"Token:", true, "", true},
+		{"", false, "", false},
+	}
+	for _, tt := range tests {
+		got, err := auth.Next([]byte(tt.prompt), tt.more)
+		if string(got) != tt.want || (err != nil) != tt.err {
+			t.Errorf("Next(%q) = %q, %v", tt.prompt, got, err)
+		}
+	}
+}
+
+// startSMTP 启动一个明文 SMTP 服务器，extensions 为 EHLO 声明的扩展，返回端口和收到的命令
+func startSMTP(t *testing.T, extensions ...string) (int, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		defer func() { commands <- received }()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			received = append(received, line)
+			cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch cmd {
+			case "EHLO":
+				lines := append([]string{"localhost"}, extensions...)
+				for i, l := range lines {
+					sep := "-"
+					if i == len(lines)-1 {
+						sep = " "
+					}
+					reply("250" + sep + l)
+				}
+			case "AUTH":
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := r.ReadString('\n')
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := r.ReadString('\n')
+				received = append(received, "user "+decodeLine(user), "pass "+decodeLine(pass))
+				reply("235 OK")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+				}
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, commands
+}
+
+func decodeLine(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	return string(b)
+}
+
+func TestDeliverMailAuth(t *testing.T) {
+	cfg := func(port int) *config.Config {
+		return &config.Config{
+			SMTPServer:     "127.0.0.1",
+			SMTPPort:       port,
+			SMTPUsername:   "bot@example.com",
+			SMTPPassword:   "secret",
+			SMTPTLSMode:    "none",
+			SMTPAuthMethod: "LOGIN",
+		}
+	}
+
+	t.Run("服务器未声明 AUTH", func(t *testing.T) {
+		port, commands := startSMTP(t, "8BITMIME")
+		err := deliverMail(cfg(port), []string{"a@example.com"}, []byte("Subject: x\r\n\r\nbody\r\n"))
+		if err == nil || !strings.Contains(err.Error(), "AUTH") {
+			t.Fatalf("未声明 AUTH 时应报错: %v", err)
+		}
+		for _, c := range <-commands {
+			if strings.HasPrefix(c, "MAIL") {
+				t.Error("未认证时不应发送邮件")
+			}
+		}
+	})
+
+	t.Run("LOGIN 认证后发送", func(t *testing.T) {
+		port, commands := startSMTP(t, "AUTH LOGIN PLAIN")
+		if err := deliverMail(cfg(port), []string{"a@example.com"}, []byte("Subject: x\r\n\r\nbody\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Join(<-commands, "\n")
+		for _, want := range []string{"AUTH LOGIN", "user bot@example.com", "pass secret", "MAIL FROM:<bot@example.com>", "RCPT TO:<a@example.com>"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("缺少命令 %q:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("未配置账号时不认证", func(t *testing.T) {
+		port, commands := startSMTP(t, "8BITMIME")
+		c := cfg(port)
+		c.SMTPUsername, c.SMTPAuthMethod = "", ""
+		if err := deliverMail(c, []string{"a@example.com"}, []byte("Subject: x\r\n\r\nbody\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(<-commands, "\n"); strings.Contains(got, "AUTH") {
+			t.Errorf("不应认证:\n%s", got)
+		}
+	})
+}
+
+func TestSMTPTLSMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		port int
+		want string
+	}{
+		{"", 25, "none"},
+		{"", 465, "tls"},
+		{"", 587, "starttls"},
+		{"TLS", 587, "tls"},
+		{"none", 465, "none"},
+		{"bogus", 465, "tls"},
+	}
+	for _, tt := range tests {
+		if got := smtpTLSMode(&config.Config{SMTPTLSMode: tt.mode, SMTPPort: tt.port}); got != tt.want {
+			t.Errorf("smtpTLSMode(%q, %d) = %s，期望 %s", tt.mode, tt.port, got, tt.want)
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"Puff/internal/config"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"log"
 	"net"
+	"net/mail"
 	"net/smtp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,36 +18,80 @@ type DomainNotification struct {
 	Domain        string
 	IsFinalNotice bool
 	Status        string
+	// Urgent 表示通知不受免打扰时段和限流约束
+	Urgent bool
+	// Channels 限定发送的渠道，为空表示所有渠道
+	Channels []string
+	Priority int
+	Tags     []string
+	// PreviousStatus 是状态变化前的状态，供路由规则匹配
+	PreviousStatus string
+	// Recipients 由路由规则填充，为空表示使用默认收件人
+	Recipients []string
+	// Changes 是 Whois 关键字段的变化明细
+	Changes []string
+	// Evidence 是各来源确认可注册的依据
+	Evidence []string
+}
+
+// routedTo 判断通知是否需要发送到指定渠道
+func (n DomainNotification) routedTo(channel string) bool {
+	if len(n.Channels) == 0 {
+		return true
+	}
+	for _, c := range n.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Message 是与具体渠道无关的通知内容
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+	// Recipients 为空时使用配置中的收件人、抄送和密送
+	Recipients []string
 }
 
 func SendNotification(notifications []DomainNotification, cfg *config.Config) error {
 	log.Printf("开始发送邮件通知")
 
+	return SendMessage(notificationMessage(notifications), cfg)
+}
+
+// SendMessage 通过邮件发送一条通知
+func SendMessage(m Message, cfg *config.Config) error {
+	to := ParseAddressList(cfg.RecipientEmail)
+	cc := ParseAddressList(cfg.SMTPCc)
+	bcc := ParseAddressList(cfg.SMTPBcc)
+	if len(m.Recipients) > 0 {
+		to, cc, bcc = m.Recipients, nil, nil
+	}
+	if len(to)+len(cc)+len(bcc) == 0 {
+		return fmt.Errorf("未配置收件人邮箱")
+	}
+
 	// 创建邮件内容
-	to := []string{cfg.RecipientEmail}
-	subject := "域名状态变更提醒"
-	body := generateEmailBody(notifications)
-
-	msg := []byte(fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/html; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s\r\n", cfg.SMTPUsername, cfg.RecipientEmail, subject, body))
-
-	// 根据端口选择不同的发送方式
-	var err error
-	switch cfg.SMTPPort {
-	case 25:
-		err = sendMailInsecure(cfg, to, msg)
-	case 465:
-		err = sendMailSSL(cfg, to, msg)
-	default: // 包括 587 端口
-		err = sendMailTLS(cfg, to, msg)
+	msg := &mailMessage{
+		From:    mail.Address{Name: cfg.SMTPFromName, Address: cfg.SMTPUsername},
+		ReplyTo: cfg.SMTPReplyTo,
+		To:      to,
+		Cc:      cc,
+		Subject: m.Subject,
+		Text:    m.Text,
+		HTML:    m.HTML,
 	}
 
+	data, err := msg.Bytes()
 	if err != nil {
+		return fmt.Errorf("生成邮件失败: %v", err)
+	}
+
+	rcpts := append(append(append([]string{}, to...), cc...), bcc...)
+	if err := deliverMail(cfg, rcpts, data); err != nil {
 		return fmt.Errorf("发送邮件失败: %v", err)
 	}
 
@@ -52,39 +99,51 @@ func SendNotification(notifications []DomainNotification, cfg *config.Config) er
 	return nil
 }
 
-func sendMailInsecure(cfg *config.Config, to []string, msg []byte) error {
-	return smtp.SendMail(
-		fmt.Sprintf("%s:%d", cfg.SMTPServer, cfg.SMTPPort),
-		nil,
-		cfg.SMTPUsername,
-		to,
-		msg,
-	)
-}
-
-func sendMailSSL(cfg *config.Config, to []string, msg []byte) error {
-	conn, err := tls.Dial("tcp", fmt.Sprintf("%s:%d", cfg.SMTPServer, cfg.SMTPPort), &tls.Config{
-		ServerName: cfg.SMTPServer,
-	})
-	if err != nil {
-		return err
+// smtpTLSMode 返回连接使用的加密方式，未显式配置时按端口推断
+func smtpTLSMode(cfg *config.Config) string {
+	switch strings.ToLower(cfg.SMTPTLSMode) {
+	case "none", "starttls", "tls":
+		return strings.ToLower(cfg.SMTPTLSMode)
 	}
-	defer conn.Close()
 
-	c, err := smtp.NewClient(conn, cfg.SMTPServer)
-	if err != nil {
-		return err
+	switch cfg.SMTPPort {
+	case 25:
+		return "none"
+	case 465:
+		return "tls"
+	default: // 包括 587 端口
+		return "starttls"
 	}
-	defer c.Quit()
+}
 
-	return sendMail(c, cfg, to, msg)
+func smtpAuth(cfg *config.Config) (smtp.Auth, error) {
+	switch strings.ToUpper(cfg.SMTPAuthMethod) {
+	case "", "PLAIN":
+		return smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPServer), nil
+	case "LOGIN":
+		return LoginAuth(cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "CRAM-MD5":
+		return smtp.CRAMMD5Auth(cfg.SMTPUsername, cfg.SMTPPassword), nil
+	}
+	return nil, fmt.Errorf("不支持的 SMTP 认证方式: %s", cfg.SMTPAuthMethod)
 }
 
-func sendMailTLS(cfg *config.Config, to []string, msg []byte) error {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", cfg.SMTPServer, cfg.SMTPPort))
+func deliverMail(cfg *config.Config, to []string, msg []byte) error {
+	addr := net.JoinHostPort(cfg.SMTPServer, strconv.Itoa(cfg.SMTPPort))
+	mode := smtpTLSMode(cfg)
+	tlsConfig := &tls.Config{ServerName: cfg.SMTPServer}
+
+	var conn net.Conn
+	var err error
+	if mode == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 30*time.Second)
+	}
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	c, err := smtp.NewClient(conn, cfg.SMTPServer)
 	if err != nil {
@@ -92,19 +151,31 @@ func sendMailTLS(cfg *config.Config, to []string, msg []byte) error {
 	}
 	defer c.Quit()
 
-	if err = c.StartTLS(&tls.Config{ServerName: cfg.SMTPServer}); err != nil {
-		return err
+	if mode == "starttls" {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	// 明文连接仅在显式指定认证方式时才进行认证
+	if cfg.SMTPUsername != "" && (mode != "none" || cfg.SMTPAuthMethod != "") {
+		auth, err := smtpAuth(cfg)
+		if err != nil {
+			return err
+		}
+		// 配置了账号但服务器未声明 AUTH 时报错，避免被降级为未认证发送
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP 服务器不支持认证（未声明 AUTH）")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
 	}
 
 	return sendMail(c, cfg, to, msg)
 }
 
 func sendMail(c *smtp.Client, cfg *config.Config, to []string, msg []byte) error {
-	auth := smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPServer)
-	if err := c.Auth(auth); err != nil {
-		return err
-	}
-
 	if err := c.Mail(cfg.SMTPUsername); err != nil {
 		return err
 	}
@@ -131,45 +202,97 @@ func sendMail(c *smtp.Client, cfg *config.Config, to []string, msg []byte) error
 	return nil
 }
 
-func generateEmailBody(notifications []DomainNotification) string {
+func generateTextBody(notifications []DomainNotification) string {
 	var body strings.Builder
 
-	body.WriteString(`
+	body.WriteString("尊敬的用户，\r\n\r\n以下域名的状态发生了变化：\r\n\r\n")
+	for _, n := range notifications {
+		body.WriteString(fmt.Sprintf("- %s: %s", n.Domain, n.Status))
+		if n.IsFinalNotice {
+			body.WriteString(" (最终通知)")
+		}
+		body.WriteString("\r\n")
+		for _, change := range n.Changes {
+			body.WriteString(fmt.Sprintf("    %s\r\n", change))
+		}
+		if len(n.Evidence) > 0 {
+			body.WriteString("    确认依据：\r\n")
+			for _, e := range n.Evidence {
+				body.WriteString(fmt.Sprintf("      %s\r\n", e))
+			}
+		}
+	}
+	body.WriteString("\r\n如果您对这些域名感兴趣，请尽快采取相应的行动。\r\n")
+	body.WriteString(fmt.Sprintf("检测时间：%s\r\n\r\n", time.Now().Format("2006年01月02日 15:04:05")))
+	body.WriteString("此邮件由 Puff 自动发送，请勿直接回复。\r\n")
+
+	return body.String()
+}
+
+func generateEmailBody(notifications []DomainNotification) string {
+	var content strings.Builder
+
+	content.WriteString(`
+            <p>尊敬的用户，</p>
+            <p>以下域名的状态发生了变化：</p>
+            <ul>
+    `)
+
+	for _, n := range notifications {
+		content.WriteString(fmt.Sprintf("<li>%s: %s", html.EscapeString(n.Domain), html.EscapeString(n.Status)))
+		if n.IsFinalNotice {
+			content.WriteString(" (最终通知)")
+		}
+		if len(n.Changes) > 0 {
+			content.WriteString("<ul>")
+			for _, change := range n.Changes {
+				content.WriteString(fmt.Sprintf("<li>%s</li>", html.EscapeString(change)))
+			}
+			content.WriteString("</ul>")
+		}
+		if len(n.Evidence) > 0 {
+			content.WriteString("<br>确认依据：<ul>")
+			for _, e := range n.Evidence {
+				content.WriteString(fmt.Sprintf("<li>%s</li>", html.EscapeString(e)))
+			}
+			content.WriteString("</ul>")
+		}
+		content.WriteString("</li>")
+	}
+
+	content.WriteString(fmt.Sprintf(`
+            </ul>
+            <p>如果您对这些域名感兴趣，请尽快采取相应的行动。</p>
+            <p>检测时间：%s</p>
+    `, time.Now().Format("2006年01月02日 15:04:05")))
+
+	return RenderEmailHTML("域名状态变更提醒", content.String())
+}
+
+// RenderEmailHTML 使用统一的邮件样式包装正文
+func RenderEmailHTML(title, content string) string {
+	return fmt.Sprintf(`
 <!DOCTYPE html>
 <html lang="zh-CN">
 <head>
     <meta charset="UTF-8">
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-        .container { width: 100%; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .container { width: 100%%; max-width: 600px; margin: 0 auto; padding: 20px; }
         .header { background-color: #161616; color: white; padding: 10px; text-align: center; }
         .content { padding: 20px; background-color: #f9f9f9; }
         .footer { text-align: center; font-size: 0.8em; color: #777; margin-top: 20px; }
+        table { border-collapse: collapse; width: 100%%; }
+        th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; }
     </style>
 </head>
 <body>
     <div class="container">
         <div class="header">
-            <h1>域名状态变更提醒</h1>
+            <h1>%s</h1>
         </div>
         <div class="content">
-            <p>尊敬的用户，</p>
-            <p>以下域名的状态发生了变化：</p>
-            <ul>
-    `)
-
-	for _, n := range notifications {
-		body.WriteString(fmt.Sprintf("<li>%s: %s", n.Domain, n.Status))
-		if n.IsFinalNotice {
-			body.WriteString(" (最终通知)")
-		}
-		body.WriteString("</li>")
-	}
-
-	body.WriteString(fmt.Sprintf(`
-            </ul>
-            <p>如果您对这些域名感兴趣，请尽快采取相应的行动。</p>
-            <p>检测时间：%s</p>
+%s
         </div>
         <div class="footer">
             <p>此邮件由 Puff 自动发送，请勿直接回复。</p>
@@ -177,7 +300,5 @@ func generateEmailBody(notifications []DomainNotification) string {
     </div>
 </body>
 </html>
-    `, time.Now().Format("2006年01月02日 15:04:05")))
-
-	return body.String()
+    `, html.EscapeString(title), content)
 }
@@ -0,0 +1,113 @@
+package notifier
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// RouteResult 是一条通知经过路由规则后的结果
+type RouteResult struct {
+	MatchedRules []string       `json:"matched_rules"`
+	UsedDefault  bool           `json:"used_default"`
+	Routes       []config.Route `json:"routes"`
+}
+
+// ResolveRoutes 按顺序匹配路由规则，没有规则匹配时使用默认路由
+func ResolveRoutes(n DomainNotification, rules *config.RoutingRules) RouteResult {
+	var result RouteResult
+	if rules == nil {
+		rules = &config.RoutingRules{}
+	}
+
+	for _, rule := range rules.Rules {
+		if !matchRule(rule.Match, n) {
+			continue
+		}
+		result.MatchedRules = append(result.MatchedRules, rule.Name)
+		result.Routes = append(result.Routes, rule.Route)
+		if rule.Stop {
+			break
+		}
+	}
+
+	if len(result.Routes) == 0 {
+		result.UsedDefault = true
+		result.Routes = []config.Route{rules.Default}
+	}
+	return result
+}
+
+func matchRule(m config.RuleMatch, n DomainNotification) bool {
+	domain := domainname.ToUnicode(n.Domain)
+	suffix, _ := publicsuffix.PublicSuffix(domainname.ToASCII(domain))
+	suffix = domainname.ToUnicode(suffix)
+	label := strings.TrimSuffix(domain, "."+suffix)
+
+	if len(m.Tags) > 0 && !anyTagMatches(m.Tags, n.Tags) {
+		return false
+	}
+	// 规则中的后缀可以写成 A-label 或 Unicode 形式
+	if len(m.TLDs) > 0 && !containsFold(m.TLDs, suffix) && !containsFold(m.TLDs, domainname.ToASCII(suffix)) {
+		return false
+	}
+	if m.MinLength > 0 && len([]rune(label)) < m.MinLength {
+		return false
+	}
+	if m.MaxLength > 0 && len([]rune(label)) > m.MaxLength {
+		return false
+	}
+	if m.Pattern != "" {
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil || !re.MatchString(domain) {
+			return false
+		}
+	}
+	if len(m.From) > 0 && !containsFold(m.From, n.PreviousStatus) {
+		return false
+	}
+	if len(m.To) > 0 && !containsFold(m.To, n.Status) {
+		return false
+	}
+	return true
+}
+
+func anyTagMatches(want, have []string) bool {
+	for _, t := range have {
+		if containsFold(want, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, item string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandRoutes 将通知按路由展开为发往指定渠道的通知列表
+func expandRoutes(notifications []DomainNotification, channel string, rules *config.RoutingRules) []DomainNotification {
+	var expanded []DomainNotification
+	for _, n := range notifications {
+		if !n.routedTo(channel) {
+			continue
+		}
+		for _, route := range ResolveRoutes(n, rules).Routes {
+			if len(route.Channels) > 0 && !containsFold(route.Channels, channel) {
+				continue
+			}
+			routed := n
+			routed.Recipients = route.Recipients
+			expanded = append(expanded, routed)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"Puff/internal/config"
+	"reflect"
+	"testing"
+)
+
+func TestResolveRoutes(t *testing.T) {
+	rules, err := config.ParseRoutingRules([]byte(`
+rules:
+  - name: vip
+    match:
+      tags: [VIP]
+    recipients: [boss@example.com]
+    stop: true
+  - name: cn
+    match:
+      tlds: [cn, 中国]
+    recipients: [cn@example.com]
+  - name: short
+    match:
+      max_length: 3
+    channels: [email]
+    recipients: [short@example.com]
+  - name: brand
+    match:
+      pattern: '^puff[0-9]*\.'
+    recipients: [brand@example.com]
+  - name: dropping
+    match:
+      from: [已注册]
+      to: [赎回期, 待删除]
+    recipients: [drop@example.com]
+default:
+  recipients: [ops@example.com]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		n       DomainNotification
+		matched []string
+		dflt    bool
+	}{
+		{"无规则匹配时使用默认路由", DomainNotification{Domain: "example.com"}, nil, true},
+		{"标签不区分大小写，stop 后不再匹配", DomainNotification{Domain: "abc.cn", Tags: []string{"vip"}}, []string{"vip"}, false},
+		{"所有匹配的规则都生效", DomainNotification{Domain: "abc.cn"}, []string{"cn", "short"}, false},
+		{"Unicode 后缀匹配", DomainNotification{Domain: "例子.中国"}, []string{"cn", "short"}, false},
+		{"A-label 形式的域名按 Unicode 后缀匹配", DomainNotification{Domain: "xn--fsqu00a.xn--fiqs8s"}, []string{"cn", "short"}, false},
+		{"长度不含后缀", DomainNotification{Domain: "abcd.com"}, nil, true},
+		{"正则匹配完整域名", DomainNotification{Domain: "puff42.net"}, []string{"brand"}, false},
+		{"正则不匹配", DomainNotification{Domain: "mypuff.net"}, nil, true},
+		{"状态变化匹配", DomainNotification{Domain: "example.com", PreviousStatus: "已注册", Status: "赎回期"}, []string{"dropping"}, false},
+		{"状态变化来源不符", DomainNotification{Domain: "example.com", PreviousStatus: "可注册", Status: "待删除"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveRoutes(tt.n, rules)
+			if !reflect.DeepEqual(got.MatchedRules, tt.matched) {
+				t.Errorf("匹配规则 %v，期望 %v", got.MatchedRules, tt.matched)
+			}
+			if got.UsedDefault != tt.dflt {
+				t.Errorf("UsedDefault = %t，期望 %t", got.UsedDefault, tt.dflt)
+			}
+			if tt.dflt && !reflect.DeepEqual(got.Routes, []config.Route{rules.Default}) {
+				t.Errorf("默认路由 %v", got.Routes)
+			}
+		})
+	}
+}
+
+func TestResolveRoutesNil(t *testing.T) {
+	got := ResolveRoutes(DomainNotification{Domain: "example.com"}, nil)
+	if !got.UsedDefault || len(got.Routes) != 1 || len(got.Routes[0].Recipients) != 0 {
+		t.Errorf("空规则应使用默认收件人: %+v", got)
+	}
+}
+
+func TestExpandRoutes(t *testing.T) {
+	rules := &config.RoutingRules{
+		Rules: []config.RoutingRule{
+			{Name: "a", Match: config.RuleMatch{Tags: []string{"a"}}, Route: config.Route{Recipients: []string{"a@example.com"}}},
+			{Name: "b", Match: config.RuleMatch{Tags: []string{"a"}}, Route: config.Route{Channels: []string{"telegram"}, Recipients: []string{"b@example.com"}}},
+			{Name: "c", Match: config.RuleMatch{Tags: []string{"a"}}, Route: config.Route{Channels: []string{"EMAIL"}, Recipients: []string{"c@example.com"}}},
+		},
+	}
+	notifications := []DomainNotification{
+		{Domain: "a.com", Tags: []string{"a"}},
+		{Domain: "b.com"},
+		{Domain: "c.com", Tags: []string{"a"}, Channels: []string{"telegram"}},
+	}
+
+	got := expandRoutes(notifications, "email", rules)
+	var recipients [][]string
+	for _, n := range got {
+		recipients = append(recipients, n.Recipients)
+	}
+	want := [][]string{{"a@example.com"}, {"c@example.com"}, nil}
+	if !reflect.DeepEqual(recipients, want) {
+		t.Errorf("收件人 %v，期望 %v", recipients, want)
+	}
+	if got[2].Domain != "b.com" {
+		t.Errorf("未匹配的通知应使用默认路由: %+v", got[2])
+	}
+}
@@ -0,0 +1,202 @@
+package outbound
+
+import (
+	"Puff/internal/config"
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+var (
+	settings config.WhoisNetwork
+	proxyURL *url.URL
+
+	// 每个目标服务器的源地址使用情况
+	nextIndex = make(map[string]int)                  // 轮流使用时下一个地址的位置
+	lastUsed  = make(map[string]map[string]time.Time) // 各地址最近一次用于该服务器的时间
+
+	mu sync.Mutex
+)
+
+// Configure 应用出站网络设置，之后建立的连接立即生效
+func Configure(network config.WhoisNetwork) error {
+	if err := network.Normalize(); err != nil {
+		return err
+	}
+
+	var u *url.URL
+	if network.Proxy != "" {
+		var err error
+		if u, err = url.Parse(network.Proxy); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	settings = network
+	proxyURL = u
+	nextIndex = make(map[string]int)
+	lastUsed = make(map[string]map[string]time.Time)
+	return nil
+}
+
+// DialContext 按出站网络设置连接 address（host:port），经过代理时源地址用于连接代理服务器
+func DialContext(ctx context.Context, address string) (net.Conn, error) {
+	mu.Lock()
+	u := proxyURL
+	pooled := len(settings.SourceAddresses) > 0
+	mu.Unlock()
+
+	// 只绑定与实际连接的主机地址族相同的源地址，否则系统无法建立连接
+	var v4, v6 bool
+	if pooled {
+		host, _, _ := net.SplitHostPort(address)
+		if u != nil {
+			host = u.Hostname()
+		}
+		v4, v6 = addressFamilies(ctx, host)
+	}
+
+	mu.Lock()
+	source := selectSource(address, v4, v6)
+	mu.Unlock()
+
+	dialer := &net.Dialer{}
+	if source != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: source}
+	}
+
+	if u == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	if u.Scheme == "http" {
+		return dialConnect(ctx, dialer, u, address)
+	}
+
+	d, err := proxy.FromURL(u, dialer)
+	if err != nil {
+		return nil, err
+	}
+	if cd, ok := d.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, "tcp", address)
+	}
+	return d.Dial("tcp", address)
+}
+
+// NewHTTPClient 返回按出站网络设置建立连接的 HTTP 客户端，用于 RDAP、HTTP 查询和注册商 API
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return DialContext(ctx, addr)
+			},
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
+
+// addressFamilies 返回主机是否有 IPv4 和 IPv6 地址，解析失败时都为 false
+func addressFamilies(ctx context.Context, host string) (v4, v6 bool) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.To4() != nil, ip.To4() == nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return false, false
+	}
+	for _, ip := range ips {
+		if ip.IP.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	return v4, v6
+}
+
+// selectSource 从地址族可用的源地址中为目标服务器选择一个，没有可用的地址时不绑定源地址。调用方需持有 mu
+func selectSource(address string, v4, v6 bool) net.IP {
+	var addrs []string
+	for _, addr := range settings.SourceAddresses {
+		ip := net.ParseIP(addr)
+		if ip != nil && (ip.To4() != nil && v4 || ip.To4() == nil && v6) {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	var chosen string
+	switch settings.Selection {
+	case config.SelectionLRU:
+		used := lastUsed[address]
+		if used == nil {
+			used = make(map[string]time.Time)
+			lastUsed[address] = used
+		}
+		for _, addr := range addrs {
+			if chosen == "" || used[addr].Before(used[chosen]) {
+				chosen = addr
+			}
+		}
+		used[chosen] = time.Now()
+	default:
+		i := nextIndex[address] % len(addrs)
+		nextIndex[address] = i + 1
+		chosen = addrs[i]
+	}
+	return net.ParseIP(chosen)
+}
+
+// dialConnect 通过 HTTP 代理的 CONNECT 方法建立隧道
+func dialConnect(ctx context.Context, dialer *net.Dialer, u *url.URL, address string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Whois 和 TLS 都由客户端先发送数据，缓冲区中不会残留隧道里的内容
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理拒绝连接 %s: %s", address, resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,173 @@
+package outbound
+
+import (
+	"Puff/internal/config"
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func configure(t *testing.T, network config.WhoisNetwork) {
+	t.Helper()
+	if err := Configure(network); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Configure(config.WhoisNetwork{}) })
+}
+
+func TestSelectSource(t *testing.T) {
+	pool := []string{"192.0.2.1", "2001:db8::1", "192.0.2.2", "2001:db8::2"}
+
+	tests := []struct {
+		name      string
+		selection string
+		v4, v6    bool
+		want      []string // 连续选择的结果，空字符串表示不绑定
+	}{
+		{"轮流使用 IPv4", config.SelectionRoundRobin, true, false, []string{"192.0.2.1", "192.0.2.2", "192.0.2.1"}},
+		{"轮流使用 IPv6", config.SelectionRoundRobin, false, true, []string{"2001:db8::1", "2001:db8::2", "2001:db8::1"}},
+		{"双栈主机使用全部地址", config.SelectionRoundRobin, true, true, []string{"192.0.2.1", "2001:db8::1", "192.0.2.2", "2001:db8::2", "192.0.2.1"}},
+		{"最久未用 IPv4", config.SelectionLRU, true, false, []string{"192.0.2.1", "192.0.2.2", "192.0.2.1"}},
+		{"无法解析时不绑定", config.SelectionRoundRobin, false, false, []string{"", ""}},
+	}
+	for _, tt := range tests {
+		configure(t, config.WhoisNetwork{SourceAddresses: pool, Selection: tt.selection})
+		for i, want := range tt.want {
+			mu.Lock()
+			got := selectSource("whois.example:43", tt.v4, tt.v6)
+			mu.Unlock()
+			if (want == "" && got != nil) || (want != "" && !got.Equal(net.ParseIP(want))) {
+				t.Errorf("%s: 第 %d 次选择 %v，期望 %s", tt.name, i+1, got, want)
+			}
+		}
+	}
+
+	// 只有 IPv6 源地址时，IPv4 主机不绑定源地址
+	configure(t, config.WhoisNetwork{SourceAddresses: []string{"2001:db8::1"}})
+	mu.Lock()
+	got := selectSource("whois.example:43", true, false)
+	mu.Unlock()
+	if got != nil {
+		t.Errorf("没有同地址族的源地址时选择了 %v", got)
+	}
+}
+
+func TestAddressFamilies(t *testing.T) {
+	ctx := context.Background()
+	if v4, v6 := addressFamilies(ctx, "192.0.2.1"); !v4 || v6 {
+		t.Errorf("IPv4 地址: %t %t", v4, v6)
+	}
+	if v4, v6 := addressFamilies(ctx, "2001:db8::1"); v4 || !v6 {
+		t.Errorf("IPv6 地址: %t %t", v4, v6)
+	}
+	if v4, v6 := addressFamilies(ctx, "nonexistent.invalid"); v4 || v6 {
+		t.Errorf("无法解析的主机: %t %t", v4, v6)
+	}
+}
+
+// listen 启动一个本地服务器，每个连接交给 handle 处理
+func listen(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// echo 回显客户端发送的第一行和客户端的地址
+func echo(conn net.Conn) {
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	io.WriteString(conn, host+" "+line)
+}
+
+func roundTrip(t *testing.T, address string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := DialContext(ctx, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "example.com\r\n")
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(reply)
+}
+
+func TestDialSkipsOtherFamily(t *testing.T) {
+	target := listen(t, echo)
+	configure(t, config.WhoisNetwork{SourceAddresses: []string{"::1", "127.0.0.1"}})
+
+	// 轮流使用时每次都应绑定 IPv4 源地址
+	for i := 0; i < 3; i++ {
+		if got := roundTrip(t, target); got != "127.0.0.1 example.com\r\n" {
+			t.Errorf("第 %d 次连接收到 %q", i+1, got)
+		}
+	}
+}
+
+func TestDialConnect(t *testing.T) {
+	target := listen(t, echo)
+
+	requests := make(chan *http.Request, 2)
+	proxyAddr := listen(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		requests <- req
+		if req.Method != http.MethodConnect || req.Host != target {
+			io.WriteString(conn, "HTTP/1.1 403 Forbidden\r\n\r\n")
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		defer upstream.Close()
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, reader)
+		io.Copy(conn, upstream)
+	})
+
+	configure(t, config.WhoisNetwork{Proxy: "http://user:secret@" + proxyAddr, SourceAddresses: []string{"::1", "127.0.0.1"}})
+	if got := roundTrip(t, target); got != "127.0.0.1 example.com\r\n" {
+		t.Errorf("经过代理收到 %q", got)
+	}
+	// user:secret 的 Base64 编码
+	if req := <-requests; req.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" || req.Host != target {
+		t.Errorf("代理收到的请求 Host %q，认证 %q", req.Host, req.Header.Get("Proxy-Authorization"))
+	}
+
+	// 代理拒绝时返回错误
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := DialContext(ctx, "127.0.0.1:1"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("代理拒绝时返回 %v", err)
+	}
+}
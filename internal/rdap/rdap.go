@@ -0,0 +1,247 @@
+package rdap
+
+import (
+	"Puff/internal/domainname"
+	"Puff/internal/outbound"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IANA 发布的 RDAP 服务引导文件
+const bootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+const bootstrapTTL = 24 * time.Hour
+
+// 连接经过 Whois 的出站网络设置（代理和源地址池）
+var client = outbound.NewHTTPClient(10 * time.Second)
+
+var (
+	services    map[string]string // TLD -> RDAP 服务地址
+	refreshedAt time.Time
+	mu          sync.Mutex
+)
+
+// Result 是一次 RDAP 查询的结果
+type Result struct {
+	Registered bool
+	StatusCode int
+	URL        string
+}
+
+// Query 通过 RDAP 查询域名是否已注册，404 视为未注册
+func Query(domain string) (Result, error) {
+	name := domainname.ToASCII(domain)
+	base, err := serviceFor(name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	resp, url, err := get(base, name)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	result := Result{StatusCode: resp.StatusCode, URL: url}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		result.Registered = true
+	case resp.StatusCode == http.StatusNotFound:
+		result.Registered = false
+	default:
+		return result, fmt.Errorf("RDAP 服务返回 %s", resp.Status)
+	}
+	return result, nil
+}
+
+// Domain 是 RDAP 域名对象中监控需要的字段
+type Domain struct {
+	Name        string
+	Found       bool
+	Registrar   string
+	NameServers []string
+	Statuses    []string
+	Expiration  time.Time
+}
+
+// Lookup 向指定的 RDAP 服务查询域名详情，404 时返回 Found 为 false 的结果
+func Lookup(domain, base string) (*Domain, error) {
+	name := domainname.ToASCII(domain)
+	resp, _, err := get(base, name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		io.Copy(io.Discard, resp.Body)
+		return &Domain{Name: name}, nil
+	default:
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("RDAP 服务返回 %s", resp.Status)
+	}
+
+	var data struct {
+		LDHName     string   `json:"ldhName"`
+		Status      []string `json:"status"`
+		Nameservers []struct {
+			LDHName string `json:"ldhName"`
+		} `json:"nameservers"`
+		Events []struct {
+			Action string `json:"eventAction"`
+			Date   string `json:"eventDate"`
+		} `json:"events"`
+		Entities []struct {
+			Roles      []string      `json:"roles"`
+			VCardArray []interface{} `json:"vcardArray"`
+		} `json:"entities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("解析 RDAP 响应失败: %w", err)
+	}
+
+	d := &Domain{Name: name, Found: true, Statuses: data.Status}
+	for _, ns := range data.Nameservers {
+		d.NameServers = append(d.NameServers, strings.ToLower(ns.LDHName))
+	}
+	for _, e := range data.Events {
+		if e.Action == "expiration" {
+			d.Expiration, _ = time.Parse(time.RFC3339, e.Date)
+		}
+	}
+	for _, e := range data.Entities {
+		for _, role := range e.Roles {
+			if role == "registrar" {
+				d.Registrar = vcardName(e.VCardArray)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Text 将查询结果转换为 Whois 格式的文本，便于与端口 43 的响应统一解析和存档
+func (d *Domain) Text() string {
+	if !d.Found {
+		return fmt.Sprintf("Domain not found: %s\n", d.Name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Domain Name: %s\n", d.Name)
+	if d.Registrar != "" {
+		fmt.Fprintf(&b, "Registrar: %s\n", d.Registrar)
+	}
+	for _, ns := range d.NameServers {
+		fmt.Fprintf(&b, "Name Server: %s\n", ns)
+	}
+	for _, s := range d.Statuses {
+		fmt.Fprintf(&b, "Domain Status: %s\n", eppStatus(s))
+	}
+	if !d.Expiration.IsZero() {
+		fmt.Fprintf(&b, "Registry Expiry Date: %s\n", d.Expiration.UTC().Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// eppStatus 将 RDAP 状态（如 "client transfer prohibited"）转换为 EPP 写法（如 clientTransferProhibited）
+func eppStatus(status string) string {
+	words := strings.Fields(status)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// vcardName 返回 jCard 中的 fn 字段
+func vcardName(vcard []interface{}) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+	properties, _ := vcard[1].([]interface{})
+	for _, p := range properties {
+		fields, _ := p.([]interface{})
+		if len(fields) < 4 || fields[0] != "fn" {
+			continue
+		}
+		if name, ok := fields[3].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func get(base, name string) (*http.Response, string, error) {
+	url := strings.TrimSuffix(base, "/") + "/domain/" + name
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, url, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+
+	resp, err := client.Do(req)
+	return resp, url, err
+}
+
+// serviceFor 返回负责该域名后缀的 RDAP 服务地址
+func serviceFor(name string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if services == nil || time.Since(refreshedAt) > bootstrapTTL {
+		loaded, err := loadBootstrap()
+		if err != nil {
+			if services == nil {
+				return "", fmt.Errorf("加载 RDAP 引导文件失败: %w", err)
+			}
+		} else {
+			services = loaded
+		}
+		// 加载失败时沿用旧数据，避免每次查询都重新请求
+		refreshedAt = time.Now()
+	}
+
+	// 从最长的后缀开始匹配
+	labels := strings.Split(name, ".")
+	for i := 1; i < len(labels); i++ {
+		if base, ok := services[strings.Join(labels[i:], ".")]; ok {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("%s 没有可用的 RDAP 服务", name)
+}
+
+func loadBootstrap() (map[string]string, error) {
+	resp, err := client.Get(bootstrapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求 %s 返回 %s", bootstrapURL, resp.Status)
+	}
+
+	var data struct {
+		Services [][][]string `json:"services"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]string)
+	for _, service := range data.Services {
+		if len(service) < 2 || len(service[1]) == 0 {
+			continue
+		}
+		for _, tld := range service[0] {
+			loaded[strings.ToLower(tld)] = service[1][0]
+		}
+	}
+	return loaded, nil
+}
@@ -0,0 +1,133 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aliyun 使用阿里云域名服务的 CheckDomain 接口查询，SaveSingleTaskForCreatingOrderActivate 接口下单
+type aliyun struct {
+	config.Registrar
+}
+
+func (a *aliyun) Check(domain string) (Result, error) {
+	code, body, err := a.call("CheckDomain", map[string]string{
+		"DomainName": domainname.ToASCII(domain),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	var resp struct {
+		Avail   string      `json:"Avail"`   // 1 可注册，0 不可注册，负数表示查询异常
+		Premium interface{} `json:"Premium"` // 不同版本返回布尔值或字符串
+		Price   int64       `json:"Price"`
+		Reason  string      `json:"Reason"`
+		Code    string      `json:"Code"`
+		Message string      `json:"Message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Result{}, fmt.Errorf("解析阿里云响应失败: %w", err)
+	}
+	if code != http.StatusOK {
+		return Result{}, fmt.Errorf("阿里云返回错误 %s: %s", resp.Code, resp.Message)
+	}
+	if resp.Avail != "1" && resp.Avail != "0" {
+		return Result{}, fmt.Errorf("阿里云无法判断: %s", resp.Reason)
+	}
+
+	result := Result{Available: resp.Avail == "1", Premium: fmt.Sprint(resp.Premium) == "true", Raw: string(body)}
+	if resp.Price > 0 {
+		result.Price = fmt.Sprintf("%d CNY", resp.Price)
+	}
+	return result, nil
+}
+
+// Register 提交域名注册任务，任务由阿里云异步执行。contact 为信息模板 ID
+func (a *aliyun) Register(req RegisterRequest) (Order, error) {
+	params := map[string]string{
+		"DomainName":           domainname.ToASCII(req.Domain),
+		"SubscriptionDuration": strconv.Itoa(req.Period),
+	}
+	if req.Contact != "" {
+		params["RegistrantProfileId"] = req.Contact
+	}
+	code, body, err := a.call("SaveSingleTaskForCreatingOrderActivate", params)
+	if err != nil {
+		return Order{}, err
+	}
+
+	var resp struct {
+		TaskNo  string `json:"TaskNo"`
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Order{}, fmt.Errorf("解析阿里云响应失败: %w", err)
+	}
+	if code != http.StatusOK || resp.TaskNo == "" {
+		return Order{}, fmt.Errorf("阿里云返回错误 %s: %s", resp.Code, resp.Message)
+	}
+	return Order{ID: resp.TaskNo, Pending: true, Raw: string(body)}, nil
+}
+
+// call 调用域名服务的接口，补全公共参数并签名
+func (a *aliyun) call(action string, params map[string]string) (int, []byte, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	params["Action"] = action
+	params["Format"] = "JSON"
+	params["Version"] = "2018-01-29"
+	params["AccessKeyId"] = a.APIKey
+	params["SignatureMethod"] = "HMAC-SHA1"
+	params["SignatureVersion"] = "1.0"
+	params["SignatureNonce"] = hex.EncodeToString(nonce)
+	params["Timestamp"] = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	query := a.sign(params)
+
+	req, err := http.NewRequest(http.MethodGet, baseURL(a.Registrar, "https://domain.aliyuncs.com")+"/?"+query, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return do(req)
+}
+
+// sign 按阿里云 RPC 签名规则（HMAC-SHA1）生成带签名的查询字符串
+func (a *aliyun) sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, percentEncode(k)+"="+percentEncode(params[k]))
+	}
+	canonical := strings.Join(pairs, "&")
+
+	mac := hmac.New(sha1.New, []byte(a.APISecret+"&"))
+	mac.Write([]byte("GET&" + percentEncode("/") + "&" + percentEncode(canonical)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return canonical + "&Signature=" + percentEncode(signature)
+}
+
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
@@ -0,0 +1,106 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dynadot 使用 api3.json 的 search 命令查询，register 命令下单
+type dynadot struct {
+	config.Registrar
+}
+
+func (d *dynadot) Check(domain string) (Result, error) {
+	body, err := d.call(url.Values{
+		"command":    {"search"},
+		"domain0":    {domainname.ToASCII(domain)},
+		"show_price": {"1"},
+		"currency":   {"USD"},
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	var resp struct {
+		SearchResponse struct {
+			ResponseCode  string `json:"ResponseCode"`
+			Error         string `json:"Error"`
+			SearchResults []struct {
+				Available string `json:"Available"`
+				Price     string `json:"Price"`
+			} `json:"SearchResults"`
+		} `json:"SearchResponse"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Result{}, fmt.Errorf("解析 Dynadot 响应失败: %w", err)
+	}
+	r := resp.SearchResponse
+	if r.ResponseCode != "0" {
+		return Result{}, fmt.Errorf("Dynadot 返回错误: %s", r.Error)
+	}
+	if len(r.SearchResults) == 0 {
+		return Result{}, errors.New("Dynadot 响应中没有查询结果")
+	}
+
+	// Dynadot 在价格说明中标注溢价域名
+	s := r.SearchResults[0]
+	return Result{
+		Available: s.Available == "yes",
+		Premium:   strings.Contains(strings.ToLower(s.Price), "premium"),
+		Price:     s.Price,
+		Raw:       string(body),
+	}, nil
+}
+
+// Register 使用账户余额注册域名，contact 为 Dynadot 联系人 ID，同时用于所有联系人类型
+func (d *dynadot) Register(req RegisterRequest) (Order, error) {
+	query := url.Values{
+		"command":  {"register"},
+		"domain":   {domainname.ToASCII(req.Domain)},
+		"duration": {strconv.Itoa(req.Period)},
+		"currency": {"USD"},
+	}
+	if req.Contact != "" {
+		for _, role := range []string{"registrant", "admin", "technical", "billing"} {
+			query.Set(role+"_contact", req.Contact)
+		}
+	}
+	body, err := d.call(query)
+	if err != nil {
+		return Order{}, err
+	}
+
+	var resp struct {
+		RegisterResponse struct {
+			ResponseCode interface{} `json:"ResponseCode"` // 不同版本返回数字或字符串
+			Status       string      `json:"Status"`
+			Error        string      `json:"Error"`
+			DomainName   string      `json:"DomainName"`
+		} `json:"RegisterResponse"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Order{}, fmt.Errorf("解析 Dynadot 响应失败: %w", err)
+	}
+	r := resp.RegisterResponse
+	if fmt.Sprint(r.ResponseCode) != "0" || r.Status != "success" {
+		return Order{}, fmt.Errorf("Dynadot 返回错误: %s", r.Error)
+	}
+	return Order{ID: r.DomainName, Raw: string(body)}, nil
+}
+
+func (d *dynadot) call(query url.Values) ([]byte, error) {
+	query.Set("key", d.APIKey)
+	req, err := http.NewRequest(http.MethodGet, baseURL(d.Registrar, "https://api.dynadot.com/api3.json")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := do(req)
+	return body, err
+}
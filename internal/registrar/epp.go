@@ -0,0 +1,131 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"Puff/internal/epp"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EPP 会话按账户名称共享，配置变更后重新建立
+var (
+	eppSessions = make(map[string]*eppSession)
+	eppMu       sync.Mutex
+)
+
+type eppSession struct {
+	config config.Registrar
+	client *epp.Client
+}
+
+// eppClient 返回该账户已登录的客户端
+func eppClient(r config.Registrar) *epp.Client {
+	eppMu.Lock()
+	defer eppMu.Unlock()
+
+	if s, ok := eppSessions[r.Name]; ok {
+		if s.config == r {
+			return s.client
+		}
+		go s.client.Close()
+	}
+
+	client := epp.NewClient(epp.Config{
+		Address:            r.Address,
+		Username:           r.Username,
+		Password:           r.Password,
+		CertFile:           r.CertFile,
+		KeyFile:            r.KeyFile,
+		CAFile:             r.CAFile,
+		InsecureSkipVerify: r.InsecureSkipVerify,
+	})
+	eppSessions[r.Name] = &eppSession{config: r, client: client}
+	return client
+}
+
+// eppChecker 使用 domain:check 查询可用性，已注册的域名再用 domain:info 获取状态和到期时间，
+// 下单使用 domain:create
+type eppChecker struct {
+	config.Registrar
+}
+
+func (e *eppChecker) Check(domain string) (Result, error) {
+	client := eppClient(e.Registrar)
+	name := domainname.ToASCII(domain)
+
+	results, err := client.Check(name)
+	if err != nil {
+		return Result{}, err
+	}
+	check := results[0]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Domain Name: %s\n", name)
+	if check.Available {
+		fmt.Fprintf(&b, "Available: yes\n")
+		return Result{Available: true, Raw: b.String()}, nil
+	}
+
+	result := Result{}
+	if check.Reason != "" {
+		fmt.Fprintf(&b, "Reason: %s\n", check.Reason)
+		result.Reason = check.Reason
+		result.Premium = strings.Contains(strings.ToLower(check.Reason), "premium")
+	}
+
+	// 非保荐注册商可能无权查看 info，此时仍以 check 的结果为准
+	info, err := client.Info(name)
+	if err != nil {
+		fmt.Fprintf(&b, "Info: %v\n", err)
+		result.Raw = b.String()
+		return result, nil
+	}
+	if info.Registrar != "" {
+		fmt.Fprintf(&b, "Registrar: %s\n", info.Registrar)
+	}
+	for _, ns := range info.NameServers {
+		fmt.Fprintf(&b, "Name Server: %s\n", ns)
+	}
+	for _, s := range info.Statuses {
+		fmt.Fprintf(&b, "Domain Status: %s\n", s)
+	}
+	if !info.Expiration.IsZero() {
+		fmt.Fprintf(&b, "Registry Expiry Date: %s\n", info.Expiration.UTC().Format(time.RFC3339))
+	}
+
+	result.Statuses = info.Statuses
+	result.Expiration = info.Expiration
+	result.Raw = b.String()
+	return result, nil
+}
+
+// Register 使用 domain:create 注册域名，转移密码随机生成
+func (e *eppChecker) Register(req RegisterRequest) (Order, error) {
+	secret := make([]byte, 8)
+	rand.Read(secret)
+
+	result, err := eppClient(e.Registrar).Create(epp.CreateRequest{
+		Name:     domainname.ToASCII(req.Domain),
+		Years:    req.Period,
+		Contact:  req.Contact,
+		AuthInfo: hex.EncodeToString(secret) + "-Pf!",
+	})
+	if err != nil {
+		return Order{}, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Domain Name: %s\n", result.Name)
+	if !result.Created.IsZero() {
+		fmt.Fprintf(&b, "Creation Date: %s\n", result.Created.UTC().Format(time.RFC3339))
+	}
+	if !result.Expiration.IsZero() {
+		fmt.Fprintf(&b, "Registry Expiry Date: %s\n", result.Expiration.UTC().Format(time.RFC3339))
+	}
+	return Order{ID: result.ServerTxID, Pending: result.Pending, Raw: b.String()}, nil
+}
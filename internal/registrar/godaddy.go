@@ -0,0 +1,53 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// godaddy 使用 /v1/domains/available 接口查询
+type godaddy struct {
+	config.Registrar
+}
+
+func (g *godaddy) Check(domain string) (Result, error) {
+	query := url.Values{
+		"domain":    {domainname.ToASCII(domain)},
+		"checkType": {"FULL"},
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL(g.Registrar, "https://api.godaddy.com")+"/v1/domains/available?"+query.Encode(), nil)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", g.APIKey, g.APISecret))
+	req.Header.Set("Accept", "application/json")
+
+	code, body, err := do(req)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var resp struct {
+		Available bool   `json:"available"`
+		Price     int64  `json:"price"` // 单位为百万分之一
+		Currency  string `json:"currency"`
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Result{}, fmt.Errorf("解析 GoDaddy 响应失败: %w", err)
+	}
+	if code != http.StatusOK {
+		return Result{}, fmt.Errorf("GoDaddy 返回错误 %s: %s", resp.Code, resp.Message)
+	}
+
+	result := Result{Available: resp.Available, Raw: string(body)}
+	if resp.Price > 0 {
+		result.Price = fmt.Sprintf("%.2f %s", float64(resp.Price)/1e6, resp.Currency)
+	}
+	return result, nil
+}
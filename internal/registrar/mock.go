@@ -0,0 +1,23 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"time"
+)
+
+// mock 不调用任何接口：查询总是返回可注册，下单总是成功且不报价，用于演练自动注册流程
+type mock struct {
+	config.Registrar
+}
+
+func (m *mock) Check(domain string) (Result, error) {
+	return Result{Available: true, Raw: fmt.Sprintf("Domain Name: %s\nAvailable: yes (mock)\n", domain)}, nil
+}
+
+func (m *mock) Register(req RegisterRequest) (Order, error) {
+	return Order{
+		ID:  fmt.Sprintf("mock-%d", time.Now().UnixNano()),
+		Raw: fmt.Sprintf("mock order: %s, %d year(s), contact %q\n", req.Domain, req.Period, req.Contact),
+	}, nil
+}
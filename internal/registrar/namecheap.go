@@ -0,0 +1,71 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// namecheap 使用 namecheap.domains.check 命令查询
+type namecheap struct {
+	config.Registrar
+}
+
+func (n *namecheap) Check(domain string) (Result, error) {
+	name := domainname.ToASCII(domain)
+	query := url.Values{
+		"ApiUser":    {n.Username},
+		"ApiKey":     {n.APIKey},
+		"UserName":   {n.Username},
+		"ClientIp":   {n.ClientIP},
+		"Command":    {"namecheap.domains.check"},
+		"DomainList": {name},
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL(n.Registrar, "https://api.namecheap.com/xml.response")+"?"+query.Encode(), nil)
+	if err != nil {
+		return Result{}, err
+	}
+
+	_, body, err := do(req)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var resp struct {
+		Status string `xml:"Status,attr"`
+		Errors []struct {
+			Number  string `xml:"Number,attr"`
+			Message string `xml:",chardata"`
+		} `xml:"Errors>Error"`
+		Results []struct {
+			Domain                   string `xml:"Domain,attr"`
+			Available                bool   `xml:"Available,attr"`
+			IsPremiumName            bool   `xml:"IsPremiumName,attr"`
+			PremiumRegistrationPrice string `xml:"PremiumRegistrationPrice,attr"`
+		} `xml:"CommandResponse>DomainCheckResult"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return Result{}, fmt.Errorf("解析 Namecheap 响应失败: %w", err)
+	}
+	if resp.Status != "OK" {
+		if len(resp.Errors) > 0 {
+			return Result{}, fmt.Errorf("Namecheap 返回错误 %s: %s", resp.Errors[0].Number, resp.Errors[0].Message)
+		}
+		return Result{}, errors.New("Namecheap 返回错误")
+	}
+	if len(resp.Results) == 0 {
+		return Result{}, errors.New("Namecheap 响应中没有查询结果")
+	}
+
+	r := resp.Results[0]
+	result := Result{Available: r.Available, Premium: r.IsPremiumName, Raw: string(body)}
+	if price, err := strconv.ParseFloat(r.PremiumRegistrationPrice, 64); err == nil && price > 0 {
+		result.Price = fmt.Sprintf("%.2f USD", price)
+	}
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/outbound"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 注册商 API 的响应正文上限
+const maxBody = 1 << 20
+
+// 注册商 API 同样经过出站代理和源地址池，Namecheap 等按 IP 白名单校验请求
+var client = outbound.NewHTTPClient(15 * time.Second)
+
+// Result 是注册商对域名可用性的判断
+type Result struct {
+	Available bool
+	Premium   bool
+	Price     string // 注册价格，如 "12.98 USD"，注册商未返回时为空
+	Raw       string // 原始响应，用于存档和展示
+
+	// 仅 EPP 提供
+	Statuses   []string // 域名状态，如 pendingDelete、redemptionPeriod
+	Expiration time.Time
+	Reason     string // domain:check 给出的不可注册原因，如 Reserved
+}
+
+// Checker 是注册商的域名可用性查询接口
+type Checker interface {
+	Check(domain string) (Result, error)
+}
+
+// RegisterRequest 是一次注册下单的参数
+type RegisterRequest struct {
+	Domain  string
+	Period  int    // 年
+	Contact string // 联系人 ID，为空时使用账户默认联系人
+}
+
+// Order 是注册商受理的订单
+type Order struct {
+	ID      string  // 注册商的订单号或任务号
+	Price   float64 // 实际扣费，注册商未返回时为 0
+	Pending bool    // 注册商异步处理，结果需在注册商后台确认
+	Raw     string
+}
+
+// Registerer 是支持下单注册的注册商接口
+type Registerer interface {
+	Register(req RegisterRequest) (Order, error)
+}
+
+// New 按注册商类型创建查询接口
+func New(r config.Registrar) (Checker, error) {
+	switch r.Type {
+	case config.RegistrarNamecheap:
+		return &namecheap{r}, nil
+	case config.RegistrarGoDaddy:
+		return &godaddy{r}, nil
+	case config.RegistrarDynadot:
+		return &dynadot{r}, nil
+	case config.RegistrarAliyun:
+		return &aliyun{r}, nil
+	case config.RegistrarEPP:
+		return &eppChecker{r}, nil
+	case config.RegistrarMock:
+		return &mock{r}, nil
+	default:
+		return nil, fmt.Errorf("注册商类型 %s 不受支持", r.Type)
+	}
+}
+
+// Check 使用 registrars.yml 中指定名称的注册商查询域名
+func Check(name, domain string) (Result, error) {
+	r, ok, err := config.FindRegistrar(name)
+	if err != nil {
+		return Result{}, err
+	}
+	if !ok {
+		return Result{}, fmt.Errorf("未配置注册商 %s", name)
+	}
+
+	checker, err := New(r)
+	if err != nil {
+		return Result{}, err
+	}
+	return checker.Check(domain)
+}
+
+// NewRegisterer 创建注册商的下单接口
+func NewRegisterer(r config.Registrar) (Registerer, error) {
+	checker, err := New(r)
+	if err != nil {
+		return nil, err
+	}
+	registerer, ok := checker.(Registerer)
+	if !ok {
+		return nil, fmt.Errorf("注册商 %s（%s）不支持自动注册", r.Name, r.Type)
+	}
+	return registerer, nil
+}
+
+func baseURL(r config.Registrar, fallback string) string {
+	if r.BaseURL != "" {
+		return r.BaseURL
+	}
+	return fallback
+}
+
+// do 发送请求并返回响应正文，非 2xx 的状态码同样返回正文，由调用方解析其中的错误信息
+func do(req *http.Request) (int, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	return resp.StatusCode, body, err
+}
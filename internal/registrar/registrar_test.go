@@ -0,0 +1,270 @@
+package registrar
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve 启动返回固定响应的注册商 API，check 检查收到的请求
+func serve(t *testing.T, status int, body string, check func(r *http.Request)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func check(t *testing.T, r config.Registrar, domain string) (Result, error) {
+	t.Helper()
+	checker, err := New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checker.Check(domain)
+}
+
+func TestNamecheap(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Result
+		err  string
+	}{
+		{
+			name: "可注册的溢价域名",
+			body: `<?xml version="1.0" encoding="utf-8"?>
+<ApiResponse Status="OK"><Errors /><CommandResponse Type="namecheap.domains.check">
+<DomainCheckResult Domain="xn--fsqu00a.com" Available="true" IsPremiumName="true" PremiumRegistrationPrice="1200.5" />
+</CommandResponse></ApiResponse>`,
+			want: Result{Available: true, Premium: true, Price: "1200.50 USD"},
+		},
+		{
+			name: "已注册",
+			body: `<ApiResponse Status="OK"><CommandResponse><DomainCheckResult Domain="xn--fsqu00a.com" Available="false" IsPremiumName="false" PremiumRegistrationPrice="0" /></CommandResponse></ApiResponse>`,
+			want: Result{},
+		},
+		{
+			name: "IP 不在白名单",
+			body: `<ApiResponse Status="ERROR"><Errors><Error Number="1011150">Invalid request IP</Error></Errors></ApiResponse>`,
+			err:  "Namecheap 返回错误 1011150: Invalid request IP",
+		},
+		{
+			name: "没有查询结果",
+			body: `<ApiResponse Status="OK"><CommandResponse /></ApiResponse>`,
+			err:  "没有查询结果",
+		},
+	}
+	for _, tt := range tests {
+		url := serve(t, http.StatusOK, tt.body, func(r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("Command") != "namecheap.domains.check" || q.Get("DomainList") != "xn--fsqu00a.com" ||
+				q.Get("ApiUser") != "alice" || q.Get("ApiKey") != "key" || q.Get("ClientIp") != "192.0.2.1" {
+				t.Errorf("%s: 请求参数 %v", tt.name, q)
+			}
+		})
+		got, err := check(t, config.Registrar{Type: config.RegistrarNamecheap, BaseURL: url, Username: "alice", APIKey: "key", ClientIP: "192.0.2.1"}, "例子.com")
+		assertResult(t, tt.name, got, err, tt.want, tt.err)
+	}
+}
+
+func TestGoDaddy(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   Result
+		err    string
+	}{
+		{"可注册", http.StatusOK, `{"available": true, "domain": "example.com", "price": 11990000, "currency": "USD"}`, Result{Available: true, Price: "11.99 USD"}, ""},
+		{"已注册", http.StatusOK, `{"available": false, "domain": "example.com"}`, Result{}, ""},
+		{"凭据错误", http.StatusUnauthorized, `{"code": "UNABLE_TO_AUTHENTICATE", "message": "Unauthorized"}`, Result{}, "GoDaddy 返回错误 UNABLE_TO_AUTHENTICATE: Unauthorized"},
+		{"响应不是 JSON", http.StatusBadGateway, `<html>Bad Gateway</html>`, Result{}, "解析 GoDaddy 响应失败"},
+	}
+	for _, tt := range tests {
+		url := serve(t, tt.status, tt.body, func(r *http.Request) {
+			if r.URL.Path != "/v1/domains/available" || r.URL.Query().Get("domain") != "example.com" ||
+				r.Header.Get("Authorization") != "sso-key key:secret" {
+				t.Errorf("%s: 请求 %s %v", tt.name, r.URL, r.Header)
+			}
+		})
+		got, err := check(t, config.Registrar{Type: config.RegistrarGoDaddy, BaseURL: url, APIKey: "key", APISecret: "secret"}, "example.com")
+		assertResult(t, tt.name, got, err, tt.want, tt.err)
+	}
+}
+
+func TestDynadot(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Result
+		err  string
+	}{
+		{"可注册", `{"SearchResponse": {"ResponseCode": "0", "SearchResults": [{"DomainName": "example.com", "Available": "yes", "Price": "10.99 in USD"}]}}`, Result{Available: true, Price: "10.99 in USD"}, ""},
+		{"溢价域名", `{"SearchResponse": {"ResponseCode": "0", "SearchResults": [{"Available": "yes", "Price": "Premium: 2500.00 in USD"}]}}`, Result{Available: true, Premium: true, Price: "Premium: 2500.00 in USD"}, ""},
+		{"已注册", `{"SearchResponse": {"ResponseCode": "0", "SearchResults": [{"Available": "no"}]}}`, Result{}, ""},
+		{"密钥错误", `{"SearchResponse": {"ResponseCode": "-1", "Error": "invalid key"}}`, Result{}, "Dynadot 返回错误: invalid key"},
+	}
+	for _, tt := range tests {
+		url := serve(t, http.StatusOK, tt.body, func(r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("command") != "search" || q.Get("domain0") != "example.com" || q.Get("key") != "key" {
+				t.Errorf("%s: 请求参数 %v", tt.name, q)
+			}
+		})
+		got, err := check(t, config.Registrar{Type: config.RegistrarDynadot, BaseURL: url, APIKey: "key"}, "example.com")
+		assertResult(t, tt.name, got, err, tt.want, tt.err)
+	}
+}
+
+func TestDynadotRegister(t *testing.T) {
+	url := serve(t, http.StatusOK, `{"RegisterResponse": {"ResponseCode": 0, "Status": "success", "DomainName": "example.com"}}`, func(r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("command") != "register" || q.Get("duration") != "2" || q.Get("registrant_contact") != "123" || q.Get("billing_contact") != "123" {
+			t.Errorf("请求参数 %v", q)
+		}
+	})
+	registerer, err := NewRegisterer(config.Registrar{Type: config.RegistrarDynadot, BaseURL: url, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := registerer.Register(RegisterRequest{Domain: "example.com", Period: 2, Contact: "123"})
+	if err != nil || order.ID != "example.com" || order.Pending {
+		t.Errorf("Register = %+v, %v", order, err)
+	}
+
+	url = serve(t, http.StatusOK, `{"RegisterResponse": {"ResponseCode": "-1", "Status": "error", "Error": "insufficient funds"}}`, nil)
+	registerer, _ = NewRegisterer(config.Registrar{Type: config.RegistrarDynadot, BaseURL: url, APIKey: "key"})
+	if _, err := registerer.Register(RegisterRequest{Domain: "example.com", Period: 1}); err == nil || !strings.Contains(err.Error(), "insufficient funds") {
+		t.Errorf("余额不足时返回 %v", err)
+	}
+}
+
+func TestAliyunSign(t *testing.T) {
+	// 阿里云 RPC 签名文档中的示例
+	a := &aliyun{config.Registrar{APISecret: "testsecret"}}
+	got := a.sign(map[string]string{
+		"Timestamp":        "2016-02-23T12:46:24Z",
+		"Format":           "XML",
+		"AccessKeyId":      "testid",
+		"Action":           "DescribeRegions",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+		"Version":          "2014-05-26",
+		"SignatureVersion": "1.0",
+	})
+	want := "AccessKeyId=testid&Action=DescribeRegions&Format=XML&SignatureMethod=HMAC-SHA1" +
+		"&SignatureNonce=3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf&SignatureVersion=1.0" +
+		"&Timestamp=2016-02-23T12%3A46%3A24Z&Version=2014-05-26&Signature=OLeaidS1JvxuMvnyHOwuJ%2BuX5qY%3D"
+	if got != want {
+		t.Errorf("sign = %s", got)
+	}
+
+	if got := percentEncode("a b*c~d+"); got != "a%20b%2Ac~d%2B" {
+		t.Errorf("percentEncode = %s", got)
+	}
+}
+
+func TestAliyun(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   Result
+		err    string
+	}{
+		{"可注册", http.StatusOK, `{"Avail": "1", "Premium": "false", "DomainName": "example.com", "RequestId": "1"}`, Result{Available: true}, ""},
+		{"溢价域名", http.StatusOK, `{"Avail": "1", "Premium": true, "Price": 8800}`, Result{Available: true, Premium: true, Price: "8800 CNY"}, ""},
+		{"已注册", http.StatusOK, `{"Avail": "0", "Reason": "Domain exists"}`, Result{}, ""},
+		{"查询异常", http.StatusOK, `{"Avail": "-1", "Reason": "Registry timeout"}`, Result{}, "阿里云无法判断: Registry timeout"},
+		{"签名错误", http.StatusBadRequest, `{"Code": "SignatureDoesNotMatch", "Message": "Specified signature is not matched"}`, Result{}, "阿里云返回错误 SignatureDoesNotMatch"},
+	}
+	for _, tt := range tests {
+		url := serve(t, tt.status, tt.body, func(r *http.Request) {
+			// 用相同的密钥重新计算签名
+			q := r.URL.Query()
+			params := make(map[string]string)
+			for k := range q {
+				if k != "Signature" {
+					params[k] = q.Get(k)
+				}
+			}
+			a := &aliyun{config.Registrar{APISecret: "secret"}}
+			if a.sign(params) != r.URL.RawQuery {
+				t.Errorf("%s: 签名不匹配 %s", tt.name, r.URL.RawQuery)
+			}
+			if q.Get("Action") != "CheckDomain" || q.Get("DomainName") != "xn--fsqu00a.cn" || q.Get("AccessKeyId") != "key" {
+				t.Errorf("%s: 请求参数 %v", tt.name, q)
+			}
+		})
+		got, err := check(t, config.Registrar{Type: config.RegistrarAliyun, BaseURL: url, APIKey: "key", APISecret: "secret"}, "例子.cn")
+		assertResult(t, tt.name, got, err, tt.want, tt.err)
+	}
+}
+
+func TestAliyunRegister(t *testing.T) {
+	url := serve(t, http.StatusOK, `{"TaskNo": "task-1", "RequestId": "1"}`, func(r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("Action") != "SaveSingleTaskForCreatingOrderActivate" || q.Get("SubscriptionDuration") != "1" || q.Get("RegistrantProfileId") != "42" {
+			t.Errorf("请求参数 %v", q)
+		}
+	})
+	registerer, err := NewRegisterer(config.Registrar{Type: config.RegistrarAliyun, BaseURL: url, APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := registerer.Register(RegisterRequest{Domain: "example.cn", Period: 1, Contact: "42"})
+	if err != nil || order.ID != "task-1" || !order.Pending {
+		t.Errorf("Register = %+v, %v", order, err)
+	}
+}
+
+func TestMock(t *testing.T) {
+	got, err := check(t, config.Registrar{Type: config.RegistrarMock}, "example.com")
+	if err != nil || !got.Available || !strings.Contains(got.Raw, "example.com") {
+		t.Errorf("Check = %+v, %v", got, err)
+	}
+	registerer, err := NewRegisterer(config.Registrar{Type: config.RegistrarMock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order, err := registerer.Register(RegisterRequest{Domain: "example.com", Period: 1}); err != nil || order.ID == "" || order.Price != 0 {
+		t.Errorf("Register = %+v, %v", order, err)
+	}
+
+	if _, err := NewRegisterer(config.Registrar{Name: "gd", Type: config.RegistrarGoDaddy}); err == nil {
+		t.Error("GoDaddy 不支持自动注册")
+	}
+	if _, err := New(config.Registrar{Type: "unknown"}); err == nil {
+		t.Error("未知的注册商类型应报错")
+	}
+}
+
+func assertResult(t *testing.T, name string, got Result, err error, want Result, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: 错误 %v，期望包含 %q", name, err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if got.Raw == "" {
+		t.Errorf("%s: 没有保留原始响应", name)
+	}
+	got.Raw = ""
+	if got.Available != want.Available || got.Premium != want.Premium || got.Price != want.Price {
+		t.Errorf("%s: %+v，期望 %+v", name, got, want)
+	}
+}
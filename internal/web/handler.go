@@ -1,21 +1,34 @@
 package web
 
 import (
+	"Puff/internal/archive"
+	"Puff/internal/backorder"
 	"Puff/internal/config"
+	"Puff/internal/digest"
+	"Puff/internal/domainname"
+	"Puff/internal/importer"
 	"Puff/internal/monitor"
 	"Puff/internal/notifier"
+	"Puff/internal/outbound"
+	"Puff/internal/registrar"
+	"Puff/internal/whois"
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func handleIndex(c *gin.Context) {
-	domains, err := config.LoadDomainList()
+	domains, err := config.LoadDomainEntries()
 	if err != nil {
 		log.Printf("加载域名错误: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -30,24 +43,116 @@ func handleIndex(c *gin.Context) {
 		"content":  "index",
 	})
 }
+
+// handleGetDomains 返回域名列表，支持按 tag、mode、owner、channel 和关键字 q 过滤
 func handleGetDomains(c *gin.Context) {
-	domains, err := config.LoadDomainList()
+	entries, err := config.LoadDomainEntries()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, domains)
+
+	tag := c.Query("tag")
+	mode := c.Query("mode")
+	owner := c.Query("owner")
+	channel := c.Query("channel")
+	keyword := strings.ToLower(c.Query("q"))
+
+	filtered := make([]config.DomainEntry, 0, len(entries))
+	for _, e := range entries {
+		if tag != "" && !e.HasTag(tag) {
+			continue
+		}
+		if mode != "" && e.Mode != mode {
+			continue
+		}
+		if owner != "" && !strings.EqualFold(e.Owner, owner) {
+			continue
+		}
+		if channel != "" && len(e.Channels) > 0 && !containsString(e.Channels, channel) {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(e.Name), keyword) &&
+			!strings.Contains(strings.ToLower(e.Note), keyword) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	c.JSON(http.StatusOK, filtered)
+}
+
+func containsString(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// domainEntryFromForm 从表单中读取域名记录
+func domainEntryFromForm(c *gin.Context) (config.DomainEntry, error) {
+	entry := config.DomainEntry{
+		Name:        c.PostForm("domain"),
+		Tags:        strings.Split(c.PostForm("tags"), ","),
+		Note:        c.PostForm("note"),
+		Owner:       c.PostForm("owner"),
+		Mode:        c.PostForm("mode"),
+		WhoisServer: c.PostForm("whois_server"),
+		Channels:    strings.Split(c.PostForm("channels"), ","),
+		DropCatch:   c.PostForm("drop_catch") == "true",
+		AlertStates: strings.Split(c.PostForm("alert_states"), ","),
+		Expected: &config.ExpectedState{
+			Registrar:   c.PostForm("expected_registrar"),
+			NameServers: strings.Split(c.PostForm("expected_name_servers"), ","),
+			Locks:       strings.Split(c.PostForm("expected_locks"), ","),
+		},
+		AutoRegister: &config.AutoRegister{
+			Enabled:   c.PostForm("auto_register") == "true",
+			Registrar: c.PostForm("auto_register_registrar"),
+			Contact:   c.PostForm("auto_register_contact"),
+		},
+	}
+
+	var err error
+	if v := c.PostForm("priority"); v != "" {
+		if entry.Priority, err = strconv.Atoi(v); err != nil {
+			return entry, fmt.Errorf("优先级必须为整数")
+		}
+	}
+	if v := c.PostForm("check_interval"); v != "" {
+		if entry.CheckInterval, err = strconv.Atoi(v); err != nil {
+			return entry, fmt.Errorf("检查间隔必须为整数")
+		}
+	}
+	if v := c.PostForm("auto_register_period"); v != "" {
+		if entry.AutoRegister.Period, err = strconv.Atoi(v); err != nil {
+			return entry, fmt.Errorf("注册年限必须为整数")
+		}
+	}
+	if v := c.PostForm("auto_register_max_price"); v != "" {
+		if entry.AutoRegister.MaxPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			return entry, fmt.Errorf("最高价格必须为数字")
+		}
+	}
+	return entry, nil
 }
 
 func handleAddDomain(c *gin.Context) {
-	domain := c.PostForm("domain")
-	if domain == "" {
+	entry, err := domainEntryFromForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if entry.Name == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "域名不能为空"})
 		return
 	}
 
-	if err := config.AddDomain(domain); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+	entry, err = config.AddDomainEntry(entry)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
@@ -61,11 +166,43 @@ func handleAddDomain(c *gin.Context) {
 	// 更新监控系统中的域名列表
 	monitor.UpdateDomainList(domains)
 
+	c.JSON(http.StatusOK, gin.H{"success": true, "domain": entry.Name, "warning": whoisServerWarning(entry)})
+}
+
+// whoisServerWarning 在域名后缀没有可用的 Whois 服务器时返回提示
+func whoisServerWarning(entry config.DomainEntry) string {
+	if entry.WhoisServer != "" {
+		return ""
+	}
+	whoisServers, err := config.LoadWhoisServers()
+	if err != nil {
+		return ""
+	}
+	if _, ok := whois.FindServer(whoisServers, entry.Name); !ok {
+		tld := domainname.ToUnicode(whois.GetTLD(entry.Name))
+		return fmt.Sprintf("未配置 %s 的 Whois 服务器，该域名将无法检查", tld)
+	}
+	return ""
+}
+
+func handleUpdateDomain(c *gin.Context) {
+	var entry config.DomainEntry
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	entry.Name = domainname.ToUnicode(c.Param("domain"))
+
+	if err := config.UpdateDomainEntry(entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
 func handleDeleteDomain(c *gin.Context) {
-	domain := c.Param("domain")
+	domain := domainname.ToUnicode(c.Param("domain"))
 	if err := config.DeleteDomain(domain); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
@@ -84,6 +221,18 @@ func handleDeleteDomain(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+func handleSetDomainOwned(c *gin.Context) {
+	domain := domainname.ToUnicode(c.Param("domain"))
+	owned := c.PostForm("owned") == "true"
+
+	if err := config.SetDomainOwned(domain, owned); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
 func handleGetWhoisServers(c *gin.Context) {
 	whoisServers, err := config.LoadWhoisServers()
 	if err != nil {
@@ -94,14 +243,27 @@ func handleGetWhoisServers(c *gin.Context) {
 }
 
 func handleAddWhoisServer(c *gin.Context) {
-	tld := c.PostForm("tld")
-	server := c.PostForm("server")
+	tld := strings.TrimSpace(c.PostForm("tld"))
+	server := config.WhoisServer{
+		Host:    c.PostForm("server"),
+		Query:   c.PostForm("query"),
+		Charset: c.PostForm("charset"),
+	}
 
-	if tld == "" || server == "" {
+	if tld == "" || strings.TrimSpace(server.Host) == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "TLD 和服务器地址都不能为空"})
 		return
 	}
 
+	if port := strings.TrimSpace(c.PostForm("port")); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "端口必须是数字"})
+			return
+		}
+		server.Port = p
+	}
+
 	if err := config.AddWhoisServer(tld, server); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
@@ -110,6 +272,25 @@ func handleAddWhoisServer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// handleReorderWhoisServers 调整后缀的服务器查询顺序
+func handleReorderWhoisServers(c *gin.Context) {
+	var req struct {
+		Servers []string `json:"servers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := config.SetWhoisServerOrder(c.Param("tld"), req.Servers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handleDeleteWhoisServer 删除后缀的一个服务器，未指定 server 参数时删除整个后缀
 func handleDeleteWhoisServer(c *gin.Context) {
 	tld := c.Param("tld")
 
@@ -118,7 +299,7 @@ func handleDeleteWhoisServer(c *gin.Context) {
 		return
 	}
 
-	if err := config.DeleteWhoisServer(tld); err != nil {
+	if err := config.DeleteWhoisServer(tld, c.Query("server")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
@@ -126,6 +307,48 @@ func handleDeleteWhoisServer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+func handleGetWhoisNetwork(c *gin.Context) {
+	network, err := config.LoadWhoisNetwork()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, network)
+}
+
+// handleSaveWhoisNetwork 保存出站代理和源地址池，并与 whois.yml 中的状态规则一起立即应用到之后的查询
+func handleSaveWhoisNetwork(c *gin.Context) {
+	var network config.WhoisNetwork
+	if err := c.ShouldBindJSON(&network); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := network.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := config.SaveWhoisNetwork(network); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := outbound.Configure(network); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := whois.ReloadStatusRules(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handleGetWhoisServerStats 返回各服务器的健康状况、耗时和错误统计
+func handleGetWhoisServerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, whois.Stats())
+}
+
 func handleGetRecipientEmail(c *gin.Context) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -165,7 +388,7 @@ func handleRefreshStatuses(c *gin.Context) {
 		return
 	}
 
-	domains, err := config.LoadDomainList()
+	domains, err := config.LoadDomainEntries()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -258,7 +481,11 @@ func handleUpdateSettings(c *gin.Context) {
 			log.Printf("加载 Whois 服务器时出错: %v", err)
 			return
 		}
-		monitor.StartMonitoring(whoisServers, &newConfig)
+		if err := whois.ReloadStatusRules(); err != nil {
+			log.Printf("加载域名状态规则时出错: %v", err)
+		}
+		// 使用重新加载的配置，其中缺失的字段已补全默认值
+		monitor.StartMonitoring(whoisServers, config.GetConfig())
 	}()
 
 	c.JSON(http.StatusOK, gin.H{"message": "设置已更新并重新加载"})
@@ -276,6 +503,12 @@ func handleAPISettings(c *gin.Context) {
 			"message": "当前配置",
 			"config": gin.H{
 				"RECIPIENT_EMAIL":         cfg.RecipientEmail,
+				"SMTP_CC":                 cfg.SMTPCc,
+				"SMTP_BCC":                cfg.SMTPBcc,
+				"SMTP_FROM_NAME":          cfg.SMTPFromName,
+				"SMTP_REPLY_TO":           cfg.SMTPReplyTo,
+				"SMTP_AUTH_METHOD":        cfg.SMTPAuthMethod,
+				"SMTP_TLS_MODE":           cfg.SMTPTLSMode,
 				"SMTP_SERVER":             cfg.SMTPServer,
 				"SMTP_PORT":               cfg.SMTPPort,
 				"SMTP_USERNAME":           cfg.SMTPUsername,
@@ -285,6 +518,31 @@ func handleAPISettings(c *gin.Context) {
 				"AUTH_PASSWORD":           cfg.AuthPassword,
 				"QUERY_FREQUENCY_SECONDS": cfg.QueryFrequencySeconds,
 				"SESSION_SECRET":          cfg.SessionSecret,
+				"DIGEST_FREQUENCY":        cfg.DigestFrequency,
+				"DIGEST_TIME":             cfg.DigestTime,
+				"DIGEST_TIMEZONE":         cfg.DigestTimezone,
+				"DIGEST_WEEKDAY":          cfg.DigestWeekday,
+				"DIGEST_RECIPIENTS":       cfg.DigestRecipients,
+				"EMAIL_QUIET_HOURS":       cfg.EmailQuietHours,
+				"EMAIL_MAX_PER_HOUR":      cfg.EmailMaxPerHour,
+				"NOTIFY_TIMEZONE":         cfg.NotifyTimezone,
+				"NOTIFY_DEDUPE_MINUTES":   cfg.NotifyDedupeMinutes,
+				"NOTIFY_URGENT_FINAL":     cfg.NotifyUrgentFinal,
+				"EXPIRY_REMINDER_DAYS":    cfg.ExpiryReminderDays,
+				"NOTIFY_FIELD_CHANGES":    cfg.NotifyFieldChanges,
+				"ARCHIVE_RETENTION_DAYS":  cfg.ArchiveRetentionDays,
+				"DNS_PREFILTER":           cfg.DNSPrefilter,
+				"DNS_RESOLVERS":           cfg.DNSResolvers,
+				"DNS_WHOIS_EVERY":         cfg.DNSWhoisEvery,
+				"CONFIRM_SOURCES":         cfg.ConfirmSources,
+				"CONFIRM_REQUIRED":        cfg.ConfirmRequired,
+				"CONFIRM_CONSECUTIVE":     cfg.ConfirmConsecutive,
+				"CONFIRM_WINDOW_MINUTES":  cfg.ConfirmWindowMinutes,
+				"AUTO_REGISTER_ENABLED":   cfg.AutoRegisterEnabled,
+				"AUTO_REGISTER_BUDGET":    cfg.AutoRegisterBudget,
+				"DROP_CATCH_INTERVAL_MS":  cfg.DropCatchIntervalMs,
+				"DROP_CATCH_RATE":         cfg.DropCatchRate,
+				"DROP_CATCH_WINDOW_HOURS": cfg.DropCatchWindowHours,
 			},
 		})
 	} else if c.Request.Method == "POST" {
@@ -305,6 +563,13 @@ func handleAPISettings(c *gin.Context) {
 
 		log.Printf("新配置保存成功: %+v", newConfig)
 
+		if err := config.ReloadConfig(); err != nil {
+			c.HTML(http.StatusInternalServerError, "layout.html", gin.H{
+				"error": "重新加载配置时出错:" + err.Error(),
+			})
+			return
+		}
+
 		// 重启监控（如果需要）
 		go func() {
 			defer func() {
@@ -317,7 +582,10 @@ func handleAPISettings(c *gin.Context) {
 				log.Printf("加载 Whois 服务器时出错: %v", err)
 				return
 			}
-			monitor.StartMonitoring(whoisServers, &newConfig)
+			if err := whois.ReloadStatusRules(); err != nil {
+				log.Printf("加载域名状态规则时出错: %v", err)
+			}
+			monitor.StartMonitoring(whoisServers, config.GetConfig())
 		}()
 
 		c.JSON(http.StatusOK, gin.H{
@@ -353,6 +621,418 @@ func handleTestEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "测试邮件发送成功"})
 }
 
+func handleTestDigest(c *gin.Context) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "加载配置失败: " + err.Error()})
+		return
+	}
+
+	// 测试摘要统计最近 24 小时
+	end := time.Now()
+	if err := digest.Send(end.Add(-24*time.Hour), end, cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "发送摘要失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "摘要发送成功"})
+}
+
+func handleImport(c *gin.Context) {
+	c.HTML(http.StatusOK, "layout.html", gin.H{
+		"title":   "批量导入导出",
+		"content": "import",
+	})
+}
+
+// buildImportReport 读取粘贴的文本或上传的文件并生成导入预览
+func buildImportReport(c *gin.Context) (*importer.Report, error) {
+	data := []byte(c.PostForm("text"))
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			return nil, err
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("没有可导入的内容")
+	}
+
+	records, err := importer.Parse(c.PostForm("format"), data)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := config.LoadDomainEntries()
+	if err != nil {
+		return nil, err
+	}
+	whoisServers, err := config.LoadWhoisServers()
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := config.DomainEntry{
+		Tags: strings.Split(c.PostForm("tags"), ","),
+		Mode: c.PostForm("mode"),
+	}
+	if err := defaults.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return importer.Preview(records, existing, whoisServers, defaults), nil
+}
+
+func handleImportPreview(c *gin.Context) {
+	report, err := buildImportReport(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "report": report})
+}
+
+func handleImportCommit(c *gin.Context) {
+	report, err := buildImportReport(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if len(report.Valid) > 0 {
+		if err := config.AddDomainEntries(report.Valid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		domains, err := config.LoadDomainList()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		monitor.UpdateDomainList(domains)
+	}
+
+	log.Printf("批量导入 %d 个域名", len(report.Valid))
+	c.JSON(http.StatusOK, gin.H{"success": true, "added": len(report.Valid), "report": report})
+}
+
+// exportRecord 是导出文件中的一行
+type exportRecord struct {
+	config.DomainEntry
+	Status            string    `json:"status"`
+	Registered        bool      `json:"registered"`
+	Redemption        bool      `json:"redemption"`
+	PendingDelete     bool      `json:"pending_delete"`
+	ExpirationDate    time.Time `json:"expiration_date"`
+	LastChecked       time.Time `json:"last_checked"`
+	StatusSince       time.Time `json:"status_since"`
+	PredictedDropDate time.Time `json:"predicted_drop_date"`
+	ErrorCount        int       `json:"error_count"`
+	LastError         string    `json:"last_error"`
+	Restriction       string    `json:"restriction"`
+}
+
+func handleExport(c *gin.Context) {
+	entries, err := config.LoadDomainEntries()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	statuses := make(map[string]monitor.DomainStatus)
+	for _, s := range monitor.GetDomainStatuses() {
+		statuses[s.Domain] = s
+	}
+
+	records := make([]exportRecord, 0, len(entries))
+	for _, e := range entries {
+		record := exportRecord{DomainEntry: e, Status: "未查询"}
+		if s, ok := statuses[e.Name]; ok && !s.LastChecked.IsZero() {
+			record.Status = monitor.GetDomainStatusString(&s)
+			record.Registered = s.Registered
+			record.Redemption = s.Redemption
+			record.PendingDelete = s.PendingDelete
+			record.ExpirationDate = s.ExpirationDate
+			record.LastChecked = s.LastChecked
+			record.StatusSince = s.StatusSince
+			record.PredictedDropDate = s.PredictedDropDate
+			record.ErrorCount = s.ErrorCount
+			record.LastError = s.LastError
+			record.Restriction = s.Restriction
+		}
+		records = append(records, record)
+	}
+
+	filename := "puff-domains-" + time.Now().Format("20060102")
+	if c.DefaultQuery("format", "csv") == "json" {
+		c.Header("Content-Disposition", "attachment; filename="+filename+".json")
+		c.JSON(http.StatusOK, records)
+		return
+	}
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xef\xbb\xbf") // 便于 Excel 识别 UTF-8
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"domain", "tags", "note", "owner", "mode", "priority", "status", "registered", "redemption",
+		"pending_delete", "expiration_date", "last_checked", "status_since", "predicted_drop_date", "error_count", "last_error", "restriction"})
+	for _, r := range records {
+		w.Write([]string{
+			r.Name, strings.Join(r.Tags, "|"), r.Note, r.Owner, r.Mode, strconv.Itoa(r.Priority), r.Status,
+			strconv.FormatBool(r.Registered), strconv.FormatBool(r.Redemption), strconv.FormatBool(r.PendingDelete),
+			formatTime(r.ExpirationDate), formatTime(r.LastChecked), formatTime(r.StatusSince),
+			formatTime(r.PredictedDropDate), strconv.Itoa(r.ErrorCount), r.LastError, r.Restriction,
+		})
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func handleRegistrars(c *gin.Context) {
+	c.HTML(http.StatusOK, "layout.html", gin.H{
+		"title":   "注册商 API",
+		"content": "registrars",
+	})
+}
+
+func handleGetRegistrars(c *gin.Context) {
+	content, err := config.LoadRegistrarsRaw()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"content": content})
+}
+
+func handleSaveRegistrars(c *gin.Context) {
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := config.SaveRegistrarsRaw(req.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handleGetOrders 返回自动注册的总开关、预算和订单记录
+func handleGetOrders(c *gin.Context) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	orders, err := backorder.LoadOrders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if orders == nil {
+		orders = []backorder.Order{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": cfg.AutoRegisterEnabled,
+		"budget":  cfg.AutoRegisterBudget,
+		"spent":   backorder.Spent(orders),
+		"orders":  orders,
+	})
+}
+
+// handleSetAutoRegister 打开或关闭自动注册总开关，下一次下单前生效
+func handleSetAutoRegister(c *gin.Context) {
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	cfg.AutoRegisterEnabled = req.Enabled
+	if err := config.SaveConfig(cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if req.Enabled {
+		log.Println("自动注册总开关已打开")
+	} else {
+		log.Println("自动注册总开关已关闭")
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "enabled": req.Enabled})
+}
+
+// handleTestRegistrar 使用指定的注册商查询域名，用于检查凭据和接口是否可用
+func handleTestRegistrar(c *gin.Context) {
+	var req struct {
+		Name   string `json:"name" binding:"required"`
+		Domain string `json:"domain" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := domainname.Normalize(req.Domain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := registrar.Check(req.Name, domain)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"domain":    domain,
+		"available": result.Available,
+		"premium":   result.Premium,
+		"price":     result.Price,
+		"reason":    result.Reason,
+		"raw":       result.Raw,
+	})
+}
+
+func handleRouting(c *gin.Context) {
+	c.HTML(http.StatusOK, "layout.html", gin.H{
+		"title":   "通知路由",
+		"content": "routing",
+	})
+}
+
+func handleGetRoutingRules(c *gin.Context) {
+	content, err := config.LoadRoutingRulesRaw()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"content": content})
+}
+
+func handleSaveRoutingRules(c *gin.Context) {
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := config.SaveRoutingRulesRaw(req.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handleTestRouting 根据域名和假设的状态变化返回通知会被发送到哪里
+func handleTestRouting(c *gin.Context) {
+	var req struct {
+		Domain string `json:"domain" binding:"required"`
+		From   string `json:"from"`
+		To     string `json:"to"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := config.LoadRoutingRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := domainname.Normalize(req.Domain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Domain = domain
+
+	entry, exists, err := config.GetDomainEntry(req.Domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !exists {
+		entry = config.DomainEntry{Name: req.Domain}
+	}
+
+	n := notifier.DomainNotification{
+		Domain:         req.Domain,
+		Status:         req.To,
+		PreviousStatus: req.From,
+		Channels:       entry.Channels,
+		Tags:           entry.Tags,
+	}
+	result := notifier.ResolveRoutes(n, rules)
+
+	// 展开为每个渠道实际使用的收件人
+	type delivery struct {
+		Channel    string   `json:"channel"`
+		Recipients []string `json:"recipients"`
+	}
+	var deliveries []delivery
+	for _, ch := range notifier.Channels() {
+		if len(entry.Channels) > 0 && !containsString(entry.Channels, ch.Name()) {
+			continue
+		}
+		for _, route := range result.Routes {
+			if len(route.Channels) > 0 && !containsString(route.Channels, ch.Name()) {
+				continue
+			}
+			recipients := route.Recipients
+			if len(recipients) == 0 {
+				recipients = notifier.ParseAddressList(cfg.RecipientEmail)
+			}
+			deliveries = append(deliveries, delivery{Channel: ch.Name(), Recipients: recipients})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"in_watchlist":  exists,
+		"tags":          entry.Tags,
+		"matched_rules": result.MatchedRules,
+		"used_default":  result.UsedDefault,
+		"deliveries":    deliveries,
+	})
+}
+
 type GithubRelease struct {
 	TagName     string    `json:"tag_name"`
 	PublishedAt time.Time `json:"published_at"`
@@ -382,3 +1062,183 @@ func handleCheckUpdate(c *gin.Context) {
 		"updateAvailable": release.TagName != currentVersion,
 	})
 }
+
+func handleLookup(c *gin.Context) {
+	c.HTML(http.StatusOK, "layout.html", gin.H{
+		"title":   "Whois 查询",
+		"content": "lookup",
+	})
+}
+
+// handleAPILookup 查询任意域名的 Whois 信息，不会加入监控列表
+func handleAPILookup(c *gin.Context) {
+	domain, err := domainname.Normalize(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	whoisServers, err := config.LoadWhoisServers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 已在监控列表中的域名沿用其单独配置的服务器
+	entry, watched, err := config.GetDomainEntry(domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !watched {
+		entry = config.DomainEntry{Name: domain}
+	}
+
+	result, err := monitor.LookupDomain(entry, whoisServers)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	hops := make([]gin.H, 0, len(result.Hops))
+	for _, hop := range result.Hops {
+		hops = append(hops, gin.H{
+			"server":      hop.Server,
+			"response":    hop.Response,
+			"error":       hop.Error,
+			"duration_ms": hop.Duration.Milliseconds(),
+		})
+	}
+
+	status := result.Status
+	c.JSON(http.StatusOK, gin.H{
+		"domain":   domain,
+		"punycode": domainname.ToASCII(domain),
+		"watched":  watched,
+		"server":   status.Server,
+		"status":   status,
+		"status_text": monitor.GetDomainStatusString(&monitor.DomainStatus{
+			Registered:    status.Registered,
+			Redemption:    status.Redemption,
+			PendingDelete: status.PendingDelete,
+			Restriction:   status.Restriction,
+		}),
+		"raw":         status.Raw,
+		"hops":        hops,
+		"duration_ms": result.Duration.Milliseconds(),
+	})
+}
+
+func handleDomainDetail(c *gin.Context) {
+	domain := domainname.ToUnicode(c.Param("domain"))
+	entry, exists, err := config.GetDomainEntry(domain)
+	if err != nil || !exists {
+		c.Redirect(http.StatusFound, "/domains")
+		return
+	}
+
+	status, checked := monitor.GetDomainStatus(domain)
+	statusText := "未查询"
+	if checked {
+		statusText = monitor.GetDomainStatusString(&status)
+	}
+
+	orders, err := backorder.LoadOrders()
+	if err != nil {
+		log.Printf("读取订单记录失败: %v", err)
+	}
+
+	c.HTML(http.StatusOK, "layout.html", gin.H{
+		"title":      domain,
+		"content":    "domain",
+		"entry":      entry,
+		"status":     status,
+		"checked":    checked,
+		"statusText": statusText,
+		"orders":     backorder.OrdersFor(orders, domain),
+	})
+}
+
+func handleGetArchive(c *gin.Context) {
+	domain, err := domainname.Normalize(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snapshots, err := archive.List(domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if snapshots == nil {
+		snapshots = []archive.Snapshot{}
+	}
+	c.JSON(http.StatusOK, gin.H{"domain": domain, "snapshots": snapshots})
+}
+
+func handleGetArchiveContent(c *gin.Context) {
+	domain, err := domainname.Normalize(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := archive.Load(domain, c.Param("hash"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"content": content})
+}
+
+// handleArchiveDiff 比较两份存档，未指定时比较最新一份与同一服务器的上一份
+func handleArchiveDiff(c *gin.Context) {
+	domain, err := domainname.Normalize(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		snapshots, err := archive.List(domain)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		from, to = "", ""
+		if n := len(snapshots); n > 0 {
+			latest := snapshots[n-1]
+			to = latest.Hash
+			for i := n - 2; i >= 0; i-- {
+				if snapshots[i].Server == latest.Server {
+					from = snapshots[i].Hash
+					break
+				}
+			}
+		}
+		if from == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "同一服务器的存档少于两份，无法比较"})
+			return
+		}
+	}
+
+	before, err := archive.Load(domain, from)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	after, err := archive.Load(domain, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"lines":   archive.Diff(before, after),
+		"changes": whois.FormatChanges(whois.ParseFields(before).Changes(whois.ParseFields(after))),
+	})
+}
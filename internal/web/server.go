@@ -45,11 +45,19 @@ func StartServer() error {
 		authorized.GET("/", handleIndex)
 		authorized.GET("/domains", handleIndex)
 		authorized.GET("/whois-servers", handleWhoisServers)
+		authorized.GET("/routing", handleRouting)
+		authorized.GET("/registrars", handleRegistrars)
+		authorized.GET("/import", handleImport)
+		authorized.GET("/lookup", handleLookup)
+		authorized.GET("/domains/:domain", handleDomainDetail)
 
 		// API 路由
 		authorized.POST("/domains", handleAddDomain)
+		authorized.PUT("/domains/:domain", handleUpdateDomain)
 		authorized.DELETE("/domains/:domain", handleDeleteDomain)
+		authorized.POST("/domains/:domain/owned", handleSetDomainOwned)
 		authorized.POST("/whois-servers", handleAddWhoisServer)
+		authorized.PUT("/whois-servers/:tld", handleReorderWhoisServers)
 		authorized.DELETE("/whois-servers/:tld", handleDeleteWhoisServer)
 		authorized.GET("/recipient-email", handleGetRecipientEmail)
 		authorized.POST("/recipient-email", handleUpdateRecipientEmail)
@@ -58,6 +66,24 @@ func StartServer() error {
 
 		authorized.GET("/api/domains", handleGetDomains)
 		authorized.GET("/api/whois-servers", handleGetWhoisServers)
+		authorized.GET("/api/whois-servers/stats", handleGetWhoisServerStats)
+		authorized.GET("/api/whois-network", handleGetWhoisNetwork)
+		authorized.POST("/api/whois-network", handleSaveWhoisNetwork)
+		authorized.GET("/api/lookup/:domain", handleAPILookup)
+		authorized.GET("/api/domains/:domain/archive", handleGetArchive)
+		authorized.GET("/api/domains/:domain/archive/:hash", handleGetArchiveContent)
+		authorized.GET("/api/domains/:domain/diff", handleArchiveDiff)
+		authorized.POST("/api/import/preview", handleImportPreview)
+		authorized.POST("/api/import/commit", handleImportCommit)
+		authorized.GET("/api/export", handleExport)
+		authorized.GET("/api/registrars", handleGetRegistrars)
+		authorized.POST("/api/registrars", handleSaveRegistrars)
+		authorized.POST("/api/registrars/test", handleTestRegistrar)
+		authorized.GET("/api/orders", handleGetOrders)
+		authorized.POST("/api/orders/switch", handleSetAutoRegister)
+		authorized.GET("/api/routing-rules", handleGetRoutingRules)
+		authorized.POST("/api/routing-rules", handleSaveRoutingRules)
+		authorized.POST("/api/routing-test", handleTestRouting)
 
 		authorized.GET("/settings", handleSettings)
 		authorized.POST("/settings", handleUpdateSettings)
@@ -65,6 +91,7 @@ func StartServer() error {
 		authorized.POST("/api/settings", handleAPISettings)
 		authorized.GET("/api/check-update", handleCheckUpdate)
 		authorized.POST("/api/test-email", handleTestEmail)
+		authorized.POST("/api/test-digest", handleTestDigest)
 	}
 
 	return r.Run(":" + strconv.Itoa(cfg.WebPort))
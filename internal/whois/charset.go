@@ -0,0 +1,90 @@
+package whois
+
+import (
+	"Puff/internal/domainname"
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// encode 按服务器配置的字符编码转换查询内容，查询模板中的 {unicode} 可能包含非 ASCII 字符
+func encode(query, charset string) ([]byte, error) {
+	charset = strings.ToLower(charset)
+	if charset == "" || charset == "utf-8" || charset == "utf8" {
+		return []byte(query), nil
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的字符编码: %s", charset)
+	}
+	encoded, err := enc.NewEncoder().Bytes([]byte(query))
+	if err != nil {
+		return nil, fmt.Errorf("按 %s 编码查询失败: %w", charset, err)
+	}
+	return encoded, nil
+}
+
+// decode 将响应转换为 UTF-8。服务器配置了字符编码（如 gbk、big5、iso-2022-jp、euc-kr、latin1）时按配置解码，
+// 否则根据响应内容和域名后缀自动识别
+func decode(data []byte, charset, domain string) (string, error) {
+	charset = strings.ToLower(charset)
+	if charset == "" {
+		charset = detectCharset(data, domain)
+	}
+	if charset == "utf-8" || charset == "utf8" {
+		return string(data), nil
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return "", fmt.Errorf("不支持的字符编码: %s", charset)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("按 %s 解码响应失败: %w", charset, err)
+	}
+	return string(decoded), nil
+}
+
+// 各后缀注册局常用的非 UTF-8 编码，按尝试顺序排列
+var tldCharsets = map[string][]string{
+	"cn": {"gb18030"},
+	"tw": {"big5"},
+	"hk": {"big5"},
+	"mo": {"big5"},
+	"jp": {"euc-jp", "shift_jis"},
+	"kr": {"euc-kr"},
+}
+
+// detectCharset 识别响应的字符编码：ISO-2022-JP 通过转义序列识别，合法的 UTF-8 原样使用，
+// 其他情况依次尝试后缀对应的常用编码，都无法完整解码时按 Latin-1 处理
+func detectCharset(data []byte, domain string) string {
+	if bytes.Contains(data, []byte("\x1b$B")) || bytes.Contains(data, []byte("\x1b$@")) {
+		return "iso-2022-jp"
+	}
+	if utf8.Valid(data) {
+		return "utf-8"
+	}
+
+	labels := strings.Split(domainname.ToASCII(domain), ".")
+	for _, charset := range tldCharsets[labels[len(labels)-1]] {
+		if decodesCleanly(data, charset) {
+			return charset
+		}
+	}
+	return "latin1"
+}
+
+// decodesCleanly 判断数据能否按指定编码解码且不产生替换字符
+func decodesCleanly(data []byte, charset string) bool {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return false
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	return err == nil && !bytes.ContainsRune(decoded, utf8.RuneError)
+}
@@ -0,0 +1,133 @@
+package whois
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func mustEncode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("编码测试数据失败: %v", err)
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		charset string // 服务器配置的字符编码
+		data    []byte
+		want    string
+		detect  string // 自动识别的编码，配置了编码时不检查
+	}{
+		{
+			name:   "UTF-8 原样保留",
+			domain: "example.com",
+			data:   []byte("Registrant: 张三\n"),
+			want:   "Registrant: 张三\n",
+			detect: "utf-8",
+		},
+		{
+			name:   "GBK",
+			domain: "例子.cn",
+			data:   mustEncode(t, simplifiedchinese.GBK, "域名：例子.cn\n注册商：阿里云计算有限公司\n"),
+			want:   "域名：例子.cn\n注册商：阿里云计算有限公司\n",
+			detect: "gb18030",
+		},
+		{
+			name:   "Big5",
+			domain: "example.tw",
+			data:   mustEncode(t, traditionalchinese.Big5, "網域名稱：example.tw\n註冊人：中華電信\n"),
+			want:   "網域名稱：example.tw\n註冊人：中華電信\n",
+			detect: "big5",
+		},
+		{
+			name:   "ISO-2022-JP 不依赖后缀",
+			domain: "example.com",
+			data:   mustEncode(t, japanese.ISO2022JP, "ドメイン名: example.com\n登録者名: 株式会社例\n"),
+			want:   "ドメイン名: example.com\n登録者名: 株式会社例\n",
+			detect: "iso-2022-jp",
+		},
+		{
+			name:   "EUC-JP",
+			domain: "example.jp",
+			data:   mustEncode(t, japanese.EUCJP, "[ドメイン名] EXAMPLE.JP\n[登録者名] 株式会社例\n"),
+			want:   "[ドメイン名] EXAMPLE.JP\n[登録者名] 株式会社例\n",
+			detect: "euc-jp",
+		},
+		{
+			name:   "EUC-KR",
+			domain: "example.kr",
+			data:   mustEncode(t, korean.EUCKR, "도메인이름: example.kr\n등록인: 홍길동\n"),
+			want:   "도메인이름: example.kr\n등록인: 홍길동\n",
+			detect: "euc-kr",
+		},
+		{
+			name:   "其他后缀按 Latin-1 处理",
+			domain: "example.de",
+			data:   mustEncode(t, charmap.ISO8859_1, "Name: Jürgen Müller\nStraße: Hauptstraße 1\n"),
+			want:   "Name: Jürgen Müller\nStraße: Hauptstraße 1\n",
+			detect: "latin1",
+		},
+		{
+			name:    "配置的编码优先于自动识别",
+			domain:  "example.cn",
+			charset: "Big5",
+			data:    mustEncode(t, traditionalchinese.Big5, "註冊人：中華電信\n"),
+			want:    "註冊人：中華電信\n",
+		},
+		{
+			name:    "配置为 UTF-8 时不转换",
+			domain:  "example.de",
+			charset: "utf-8",
+			data:    []byte("Name: J\xfcrgen\n"),
+			want:    "Name: J\xfcrgen\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.charset == "" {
+			if got := detectCharset(tt.data, tt.domain); got != tt.detect {
+				t.Errorf("%s: detectCharset = %s，期望 %s", tt.name, got, tt.detect)
+			}
+		}
+		got, err := decode(tt.data, tt.charset, tt.domain)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: decode = %q，期望 %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := decode([]byte("x"), "no-such-charset", "example.com"); err == nil {
+		t.Error("不支持的字符编码应报错")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got, err := encode("例子.cn\r\n", "GBK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustEncode(t, simplifiedchinese.GBK, "例子.cn\r\n"); string(got) != string(want) {
+		t.Errorf("encode = %x，期望 %x", got, want)
+	}
+
+	if got, err := encode("例子.cn", ""); err != nil || string(got) != "例子.cn" {
+		t.Errorf("未配置编码时 encode = %q, %v", got, err)
+	}
+	if _, err := encode("例子.cn", "latin1"); err == nil {
+		t.Error("无法用 Latin-1 表示的查询应报错")
+	}
+}
@@ -0,0 +1,152 @@
+package whois
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields 是 Whois 响应中用于比较变化的关键字段
+type Fields struct {
+	Registrar      string
+	NameServers    []string
+	Statuses       []string
+	ExpirationDate time.Time
+}
+
+// 注册商字段
+func registrarKeys() []string {
+	return []string{
+		"registrar",
+		"sponsoring registrar",
+		"registrar name",
+	}
+}
+
+// 域名服务器字段
+func nameServerKeys() []string {
+	return []string{
+		"name server",
+		"nserver",
+		"nameserver",
+		"name servers",
+		"dns",
+	}
+}
+
+// 域名状态字段
+func statusKeys() []string {
+	return []string{
+		"domain status",
+		"status",
+		"state",
+	}
+}
+
+// ParseFields 从 Whois 原始响应中提取注册商、域名服务器、状态和到期时间
+func ParseFields(response string) Fields {
+	var fields Fields
+	nameServers := make(map[string]bool)
+	statuses := make(map[string]bool)
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		value := strings.TrimSpace(line[idx+1:])
+		if value == "" {
+			continue
+		}
+
+		switch {
+		case containsKey(registrarKeys(), key):
+			if fields.Registrar == "" {
+				fields.Registrar = value
+			}
+		case containsKey(nameServerKeys(), key):
+			// 部分注册局会在域名服务器后附带 IP 地址
+			ns := strings.TrimSuffix(strings.ToLower(strings.Fields(value)[0]), ".")
+			nameServers[ns] = true
+		case containsKey(statusKeys(), key):
+			// 去掉 EPP 状态后面的说明链接，如 "clientTransferProhibited https://icann.org/epp#..."
+			statuses[strings.Fields(value)[0]] = true
+		}
+	}
+
+	fields.NameServers = sortedKeys(nameServers)
+	fields.Statuses = sortedKeys(statuses)
+	fields.ExpirationDate = parseExpirationDate(response)
+	return fields
+}
+
+// FieldChange 描述一个关键字段的变化
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.Before, c.After)
+}
+
+// Changes 返回从 f 到 other 之间关键字段的变化
+func (f Fields) Changes(other Fields) []FieldChange {
+	var changes []FieldChange
+	add := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, FieldChange{Field: field, Before: orNone(before), After: orNone(after)})
+		}
+	}
+	add("注册商", f.Registrar, other.Registrar)
+	add("域名服务器", strings.Join(f.NameServers, ", "), strings.Join(other.NameServers, ", "))
+	add("状态", strings.Join(f.Statuses, ", "), strings.Join(other.Statuses, ", "))
+	add("到期时间", formatDate(f.ExpirationDate), formatDate(other.ExpirationDate))
+	return changes
+}
+
+// FormatChanges 将字段变化转换为便于展示的文本
+func FormatChanges(changes []FieldChange) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return lines
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "无"
+	}
+	return s
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "未知"
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,145 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"sync"
+	"time"
+)
+
+// 熔断参数
+const (
+	breakerThreshold   = 3                // 连续失败多少次后熔断
+	breakerCooldown    = time.Minute      // 首次熔断的时长，之后每次翻倍
+	maxBreakerCooldown = 30 * time.Minute // 熔断时长上限
+	scoreWeight        = 0.2              // 健康分的指数加权系数
+	degradedScore      = 0.7              // 健康分低于该值视为降级
+)
+
+// 服务器状态
+const (
+	StateHealthy  = "healthy"
+	StateDegraded = "degraded"
+	StateOpen     = "open"
+)
+
+// ServerStats 是一个服务器的查询统计
+type ServerStats struct {
+	Server              string    `json:"server"`
+	State               string    `json:"state"`
+	Score               float64   `json:"score"` // 成功率的指数加权平均，1 表示完全健康
+	Successes           int       `json:"successes"`
+	Failures            int       `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	AvgLatencyMs        int64     `json:"avg_latency_ms"` // 成功查询耗时的指数加权平均
+	LastError           string    `json:"last_error"`
+	LastErrorAt         time.Time `json:"last_error_at"`
+	OpenUntil           time.Time `json:"open_until"`
+}
+
+type serverHealth struct {
+	stats   ServerStats
+	latency time.Duration
+	trips   int // 连续熔断的次数
+}
+
+var (
+	health   = make(map[string]*serverHealth)
+	healthMu sync.Mutex
+)
+
+func healthOf(server string) *serverHealth {
+	h, ok := health[server]
+	if !ok {
+		h = &serverHealth{stats: ServerStats{Server: server, Score: 1}}
+		health[server] = h
+	}
+	return h
+}
+
+// record 记录一次查询的结果，连续失败达到阈值时熔断该服务器
+func record(server string, latency time.Duration, err error) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	h := healthOf(server)
+	s := &h.stats
+	if err == nil {
+		s.Successes++
+		s.ConsecutiveFailures = 0
+		s.Score = s.Score*(1-scoreWeight) + scoreWeight
+		if h.latency == 0 {
+			h.latency = latency
+		} else {
+			h.latency = time.Duration(float64(h.latency)*(1-scoreWeight) + float64(latency)*scoreWeight)
+		}
+		h.trips = 0
+		s.OpenUntil = time.Time{}
+		return
+	}
+
+	s.Failures++
+	s.ConsecutiveFailures++
+	s.Score = s.Score * (1 - scoreWeight)
+	s.LastError = err.Error()
+	s.LastErrorAt = time.Now()
+
+	// 半开状态下的试探失败会再次熔断，时长翻倍
+	if s.ConsecutiveFailures >= breakerThreshold {
+		cooldown := breakerCooldown << h.trips
+		if cooldown > maxBreakerCooldown || cooldown <= 0 {
+			cooldown = maxBreakerCooldown
+		}
+		h.trips++
+		s.OpenUntil = time.Now().Add(cooldown)
+	}
+}
+
+func (h *serverHealth) state(now time.Time) string {
+	switch {
+	case now.Before(h.stats.OpenUntil):
+		return StateOpen
+	case h.stats.Score < degradedScore:
+		return StateDegraded
+	default:
+		return StateHealthy
+	}
+}
+
+// candidates 按健康状况排列服务器：健康的服务器按配置顺序在前，降级的在后，熔断中的跳过
+func candidates(servers []config.WhoisServer) []config.WhoisServer {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	now := time.Now()
+	var healthy, degraded []config.WhoisServer
+	for _, server := range servers {
+		h, ok := health[server.Address()]
+		if !ok {
+			healthy = append(healthy, server)
+			continue
+		}
+		switch h.state(now) {
+		case StateHealthy:
+			healthy = append(healthy, server)
+		case StateDegraded:
+			degraded = append(degraded, server)
+		}
+	}
+	return append(healthy, degraded...)
+}
+
+// Stats 返回所有查询过的服务器的统计
+func Stats() map[string]ServerStats {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	now := time.Now()
+	stats := make(map[string]ServerStats, len(health))
+	for server, h := range health {
+		s := h.stats
+		s.State = h.state(now)
+		s.AvgLatencyMs = h.latency.Milliseconds()
+		stats[server] = s
+	}
+	return stats
+}
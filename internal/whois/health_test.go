@@ -0,0 +1,144 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startWhois 启动一个本地 Whois 服务器，respond 根据收到的查询返回响应，返回空字符串时直接断开
+func startWhois(t *testing.T, respond func(query string) string) config.WhoisServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				conn.Write([]byte(respond(strings.TrimRight(query, "\r\n"))))
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return config.WhoisServer{Host: "127.0.0.1", Port: addr.Port}
+}
+
+func resetHealth(t *testing.T) {
+	healthMu.Lock()
+	health = make(map[string]*serverHealth)
+	healthMu.Unlock()
+	t.Cleanup(func() {
+		healthMu.Lock()
+		health = make(map[string]*serverHealth)
+		healthMu.Unlock()
+	})
+}
+
+func TestBreaker(t *testing.T) {
+	resetHealth(t)
+	servers := []config.WhoisServer{{Host: "a.example"}, {Host: "b.example"}, {Host: "c.example"}}
+	addresses := func(servers []config.WhoisServer) string {
+		var s []string
+		for _, server := range servers {
+			s = append(s, server.Address())
+		}
+		return strings.Join(s, ",")
+	}
+	fail := errors.New("timeout")
+
+	// 偶发失败使健康分下降，排到健康的服务器之后
+	record("a.example", time.Millisecond, fail)
+	record("a.example", time.Millisecond, fail)
+	if got := addresses(candidates(servers)); got != "b.example,c.example,a.example" {
+		t.Errorf("降级后的顺序 %s", got)
+	}
+
+	// 连续失败达到阈值后熔断
+	record("a.example", time.Millisecond, fail)
+	if got := addresses(candidates(servers)); got != "b.example,c.example" {
+		t.Errorf("熔断后的候选 %s", got)
+	}
+	stats := Stats()["a.example"]
+	if stats.State != StateOpen || stats.ConsecutiveFailures != breakerThreshold || stats.LastError != "timeout" {
+		t.Errorf("熔断状态 %+v", stats)
+	}
+	if d := time.Until(stats.OpenUntil); d <= 0 || d > breakerCooldown {
+		t.Errorf("首次熔断时长 %s", d)
+	}
+
+	// 冷却结束后的试探失败会再次熔断，时长翻倍
+	healthMu.Lock()
+	health["a.example"].stats.OpenUntil = time.Now().Add(-time.Second)
+	healthMu.Unlock()
+	if got := addresses(candidates(servers)); got != "b.example,c.example,a.example" {
+		t.Errorf("冷却结束后的候选 %s", got)
+	}
+	record("a.example", time.Millisecond, fail)
+	if d := time.Until(Stats()["a.example"].OpenUntil); d <= breakerCooldown || d > 2*breakerCooldown {
+		t.Errorf("再次熔断时长 %s", d)
+	}
+
+	// 成功后解除熔断
+	record("a.example", 10*time.Millisecond, nil)
+	stats = Stats()["a.example"]
+	if stats.State == StateOpen || stats.ConsecutiveFailures != 0 || stats.AvgLatencyMs != 10 || stats.Successes != 1 || stats.Failures != 4 {
+		t.Errorf("恢复后的状态 %+v", stats)
+	}
+
+	// 所有服务器都熔断时不查询
+	for _, server := range servers {
+		for i := 0; i < breakerThreshold; i++ {
+			record(server.Address(), time.Millisecond, fail)
+		}
+	}
+	if _, err := Query("example.com", servers); err == nil || !strings.Contains(err.Error(), "熔断") {
+		t.Errorf("所有服务器熔断时 Query 返回 %v", err)
+	}
+}
+
+func TestQueryFailover(t *testing.T) {
+	resetHealth(t)
+	closed := startWhois(t, func(string) string { return "" })
+	throttled := startWhois(t, func(string) string { return "Error: query rate limit exceeded, try again later\n" })
+	ok := startWhois(t, func(query string) string {
+		return "Domain Name: " + query + "\nRegistry Expiry Date: 2030-01-01T00:00:00Z\n"
+	})
+
+	status, err := Query("example.com", []config.WhoisServer{closed, throttled, ok})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Registered || status.Server != ok.Address() || status.Source != SourceWhois {
+		t.Errorf("切换后的结果 %+v", status)
+	}
+	if status.ExpirationDate.Year() != 2030 {
+		t.Errorf("到期时间 %s", status.ExpirationDate)
+	}
+
+	stats := Stats()
+	if stats[closed.Address()].Failures != 1 || stats[throttled.Address()].Failures != 1 || stats[ok.Address()].Successes != 1 {
+		t.Errorf("查询统计 %+v", stats)
+	}
+
+	if _, err := Query("example.com", []config.WhoisServer{closed, throttled}); err == nil ||
+		!strings.Contains(err.Error(), closed.Address()) || !strings.Contains(err.Error(), "限流") {
+		t.Errorf("全部失败时 Query 返回 %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/outbound"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTP 查询的响应正文上限
+const maxHTTPBody = 1 << 20
+
+var httpClient = outbound.NewHTTPClient(queryTimeout)
+
+// checkHTTP 按配置发送 HTTP 请求，并用第一条匹配的规则判断域名状态，没有规则匹配时返回错误
+func checkHTTP(domain string, check config.HTTPCheck, charset string) (DomainStatus, error) {
+	method := check.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if check.Body != "" {
+		body = strings.NewReader(expandTemplate(check.Body, domain))
+	}
+	req, err := http.NewRequest(method, expandTemplate(check.URL, domain), body)
+	if err != nil {
+		return DomainStatus{}, err
+	}
+	for key, value := range check.Headers {
+		req.Header.Set(key, expandTemplate(value, domain))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return DomainStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	if err != nil {
+		return DomainStatus{}, err
+	}
+	text, err := decode(data, charset, domain)
+	if err != nil {
+		return DomainStatus{}, err
+	}
+
+	// 正文不是 JSON 时，JSONPath 规则不会匹配
+	var doc interface{}
+	if json.Unmarshal([]byte(text), &doc) != nil {
+		doc = nil
+	}
+
+	for _, rule := range check.Rules {
+		if !matchRule(rule, resp.StatusCode, text, doc) {
+			continue
+		}
+		status := DomainStatus{
+			Domain:         domain,
+			Registered:     rule.Status != config.HTTPStatusAvailable,
+			Redemption:     rule.Status == config.HTTPStatusRedemption,
+			PendingDelete:  rule.Status == config.HTTPStatusPendingDelete,
+			ExpirationDate: parseExpirationDate(text),
+			Raw:            text,
+		}
+		return status, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return DomainStatus{Raw: text}, fmt.Errorf("HTTP 服务返回 %s", resp.Status)
+	}
+	return DomainStatus{Raw: text}, fmt.Errorf("响应没有匹配任何规则")
+}
+
+// matchRule 判断响应是否满足规则中设置的全部条件
+func matchRule(rule config.HTTPRule, statusCode int, text string, doc interface{}) bool {
+	if rule.StatusCode != 0 && rule.StatusCode != statusCode {
+		return false
+	}
+
+	if rule.JSONPath != "" {
+		value, ok := jsonPath(doc, rule.JSONPath)
+		if !ok {
+			return false
+		}
+		if rule.Equals == "" {
+			if !truthy(value) {
+				return false
+			}
+		} else if !strings.EqualFold(fmt.Sprint(value), rule.Equals) {
+			return false
+		}
+	}
+
+	if rule.Regex != "" {
+		pattern := rule.Pattern()
+		if pattern == nil || !pattern.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonPath 按简化的 JSONPath（如 $.data.items[0].status）取值
+func jsonPath(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(path, "$")
+	current := doc
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+
+		// 拆分 name[0][1] 形式的下标
+		name := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			for _, idx := range strings.Split(part[i+1:], "[") {
+				indexes = append(indexes, strings.TrimSuffix(idx, "]"))
+			}
+		}
+
+		if name != "" {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[name]; !ok {
+				return nil, false
+			}
+		}
+
+		for _, idx := range indexes {
+			arr, ok := current.([]interface{})
+			n, err := strconv.Atoi(idx)
+			if !ok || err != nil || n < 0 || n >= len(arr) {
+				return nil, false
+			}
+			current = arr[n]
+		}
+	}
+	return current, current != nil
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v != "" && !strings.EqualFold(v, "false")
+	case float64:
+		return v != 0
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,74 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCheckHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("d") {
+		case "free.test":
+			fmt.Fprint(w, `{"data": {"available": true}}`)
+		case "drop.test":
+			fmt.Fprint(w, "Status: Pending Delete")
+		default:
+			fmt.Fprint(w, "Domain is taken")
+		}
+	}))
+	defer srv.Close()
+
+	var server config.WhoisServer
+	content := fmt.Sprintf(`
+http:
+  url: %s/?d={domain}
+  rules:
+    - status: available
+      jsonpath: $.data.available
+    - status: pendingDelete
+      regex: (?i)pending\s+delete
+    - status: registered
+      regex: taken
+`, srv.URL)
+	if err := yaml.Unmarshal([]byte(content), &server); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		domain                    string
+		registered, pendingDelete bool
+	}{
+		{"free.test", false, false},
+		{"drop.test", true, true},
+		{"used.test", true, false},
+	}
+	for _, tt := range tests {
+		status, err := checkHTTP(tt.domain, *server.HTTP, "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.domain, err)
+			continue
+		}
+		if status.Registered != tt.registered || status.PendingDelete != tt.pendingDelete {
+			t.Errorf("%s: %+v", tt.domain, status)
+		}
+	}
+}
+
+func TestHTTPRuleInvalidRegex(t *testing.T) {
+	server := config.WhoisServer{HTTP: &config.HTTPCheck{
+		URL:   "https://example.test/?d={domain}",
+		Rules: []config.HTTPRule{{Status: config.HTTPStatusAvailable, Regex: "("}},
+	}}
+	if err := server.Normalize(); err == nil || !strings.Contains(err.Error(), "正则") {
+		t.Errorf("无效的正则表达式应在加载时报错: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 最多跟随的转介次数
+const maxReferrals = 2
+
+// Hop 是查询链上的一次 Whois 查询
+type Hop struct {
+	Server   string        `json:"server"`
+	Response string        `json:"response"`
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// LookupResult 是一次完整查询的结果，Status 由第一个成功应答的服务器（注册局）的响应解析得出，
+// 与监控使用的判断逻辑一致；后续的转介服务器只用于展示
+type LookupResult struct {
+	Status   DomainStatus  `json:"status"`
+	Hops     []Hop         `json:"hops"`
+	Duration time.Duration `json:"-"`
+}
+
+// 响应中指向下一级 Whois 服务器的字段
+func referralKeys() []string {
+	return []string{
+		"registrar whois server",
+		"whois server",
+		"refer",
+		"whois",
+	}
+}
+
+// Lookup 按与监控相同的故障转移顺序查询域名，并跟随响应中的转介，返回每一跳的原始响应和耗时。
+// 失败的服务器也会作为一跳记录在结果中。
+func Lookup(domain string, servers []config.WhoisServer) (*LookupResult, error) {
+	start := time.Now()
+	result := &LookupResult{}
+
+	available := candidates(servers)
+	if len(available) == 0 {
+		return nil, fmt.Errorf("%s 的 Whois 服务器均处于熔断状态", domain)
+	}
+
+	var answered config.WhoisServer
+	var err error
+	for _, server := range available {
+		hopStart := time.Now()
+		var status DomainStatus
+		status, err = queryServer(domain, server)
+		hop := Hop{Server: server.Address(), Response: status.Raw, Duration: time.Since(hopStart)}
+		if err != nil {
+			hop.Error = err.Error()
+		}
+		result.Hops = append(result.Hops, hop)
+
+		if err == nil {
+			result.Status = status
+			answered = server
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有 Whois 服务器的响应中包含转介
+	if answered.Registrar != "" || answered.HTTP != nil || answered.IsRDAP() {
+		result.Duration = time.Since(start)
+		return result, nil
+	}
+
+	// 转介的注册商服务器只用于展示，不计入健康统计
+	visited := map[string]bool{result.Status.Server: true}
+	server := referralServer(result.Status.Raw)
+	for i := 0; i < maxReferrals && server != "" && !visited[server]; i++ {
+		visited[server] = true
+
+		hopStart := time.Now()
+		response, err := queryRaw(domain, config.WhoisServer{Host: server})
+		hop := Hop{Server: server, Response: response, Duration: time.Since(hopStart)}
+		if err != nil {
+			hop.Error = err.Error()
+		}
+		result.Hops = append(result.Hops, hop)
+
+		if err != nil {
+			break
+		}
+		server = referralServer(response)
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// referralServer 从响应中提取下一级 Whois 服务器
+func referralServer(response string) string {
+	for _, line := range strings.Split(response, "\n") {
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		for _, k := range referralKeys() {
+			if key != k {
+				continue
+			}
+			value := strings.TrimSpace(line[idx+1:])
+			value = strings.TrimPrefix(value, "whois://")
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "http://"), "/")
+			if value != "" && !strings.ContainsAny(value, " /") {
+				return strings.ToLower(value)
+			}
+		}
+	}
+	return ""
+}
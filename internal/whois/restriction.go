@@ -0,0 +1,180 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 未注册但不能正常注册的状态
+const (
+	RestrictionReserved = "reserved" // 注册局保留
+	RestrictionPremium  = "premium"  // 注册局溢价域名
+	RestrictionBlocked  = "blocked"  // 被 DPML 等商标保护机制屏蔽
+	RestrictionInvalid  = "invalid"  // 名称不符合注册规则
+)
+
+// Restrictions 按识别顺序列出所有受限状态
+var Restrictions = []string{RestrictionInvalid, RestrictionBlocked, RestrictionReserved, RestrictionPremium}
+
+// 内置的识别规则，键 default 适用于所有后缀。
+// 短语避免使用单独的 reserved、premium、dpml 等词，以免误匹配版权声明和广告
+var builtinStatusRules = map[string]config.StatusRules{
+	"default": {
+		Reserved: []string{
+			"reserved domain",
+			"reserved name",
+			"domain is reserved",
+			"name is reserved",
+			"reserved by the registry",
+			"registry reserved",
+			"status: reserved",
+		},
+		Premium: []string{
+			"premium domain",
+			"premium name",
+			"is a premium",
+			"status: premium",
+		},
+		Blocked: []string{
+			"blocked by dpml",
+			"dpml block",
+			"domain is blocked",
+			"name is blocked",
+			"status: blocked",
+		},
+		Invalid: []string{
+			"invalid domain",
+			"invalid query",
+			"domain name is not valid",
+			"not a valid domain",
+			"status: invalid",
+		},
+	},
+	"de": {
+		Invalid: []string{"status: invalid"},
+	},
+	"eu": {
+		Reserved: []string{"status: not available"},
+		Invalid:  []string{"status: not allowed"},
+	},
+	"uk": {
+		Invalid: []string{"contravenes the nominet uk naming rules"},
+	},
+}
+var (
+	statusRules   map[string]config.StatusRules
+	statusRulesMu sync.RWMutex
+)
+
+// ConfigureStatusRules 应用 whois.yml 中的识别规则，与内置规则合并使用
+func ConfigureStatusRules(rules map[string]config.StatusRules) {
+	statusRulesMu.Lock()
+	defer statusRulesMu.Unlock()
+	statusRules = rules
+}
+
+// ReloadStatusRules 重新读取 whois.yml 中的识别规则并应用
+func ReloadStatusRules() error {
+	rules, err := config.LoadStatusRules()
+	if err != nil {
+		return err
+	}
+	ConfigureStatusRules(rules)
+	return nil
+}
+
+// messageLines 是响应开头参与识别的提示行数，之后的内容通常是法律声明和页脚
+const messageLines = 3
+
+var keyLine = regexp.MustCompile(`^([a-z][a-z0-9 ._/-]{0,40}):(\s|$)`)
+
+// statusLines 返回响应中参与识别的行：任意位置的状态字段，以及开头不超过 messageLines 行的
+// 提示信息。提示信息遇到其他字段（如 Domain Name:、NOTICE:）即结束
+func statusLines(responseLower string) []string {
+	var lines []string
+	messages := 0
+	for _, line := range strings.Split(responseLower, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+		if m := keyLine.FindStringSubmatch(line); m != nil {
+			// 字段名以 status 或 state 结尾（如 Domain Status:）才是状态字段
+			if key := strings.Fields(m[1]); key[len(key)-1] == "status" || key[len(key)-1] == "state" {
+				lines = append(lines, line)
+			}
+			messages = messageLines
+			continue
+		}
+		if messages < messageLines {
+			lines = append(lines, line)
+			messages++
+		}
+	}
+	return lines
+}
+
+// detectRestriction 按内置规则和配置的规则识别响应中的受限状态，未识别时返回空字符串
+func detectRestriction(domain, responseLower string) string {
+	keys := ruleKeys(domain)
+	lines := statusLines(responseLower)
+
+	statusRulesMu.RLock()
+	defer statusRulesMu.RUnlock()
+
+	for _, restriction := range Restrictions {
+		for _, key := range keys {
+			for _, rules := range []map[string]config.StatusRules{builtinStatusRules, statusRules} {
+				for _, phrase := range rulePhrases(rules[key], restriction) {
+					if phrase = strings.ToLower(strings.TrimSpace(phrase)); phrase != "" && anyLineContains(lines, phrase) {
+						return restriction
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ruleKeys 返回适用于域名的规则键：default、公共后缀及其上级后缀（如 co.uk 和 uk），同时包括 Unicode 形式
+func ruleKeys(domain string) []string {
+	keys := []string{"default"}
+	suffix := GetTLD(domain)
+	for {
+		keys = append(keys, suffix)
+		if unicode := domainname.ToUnicode(suffix); unicode != suffix {
+			keys = append(keys, unicode)
+		}
+		i := strings.IndexByte(suffix, '.')
+		if i < 0 {
+			return keys
+		}
+		suffix = suffix[i+1:]
+	}
+}
+
+func anyLineContains(lines []string, phrase string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func rulePhrases(r config.StatusRules, restriction string) []string {
+	switch restriction {
+	case RestrictionReserved:
+		return r.Reserved
+	case RestrictionPremium:
+		return r.Premium
+	case RestrictionBlocked:
+		return r.Blocked
+	case RestrictionInvalid:
+		return r.Invalid
+	}
+	return nil
+}
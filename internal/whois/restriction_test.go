@@ -0,0 +1,82 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseResponseRestriction(t *testing.T) {
+	footer := `
+>>> Last update of whois database: 2026-10-01T00:00:00Z <<<
+
+NOTICE: Some names may be blocked by DPML or other brand protection
+programs and are not available for registration. This notice is part of
+the registry's terms of use and describes reserved names in general.
+`
+	tests := []struct {
+		name       string
+		domain     string
+		response   string
+		want       string
+		registered bool
+	}{
+		{"页脚中的短语不影响已注册域名", "example.com", "Domain Name: EXAMPLE.COM\nRegistrar: Example Registrar\nName Server: NS1.EXAMPLE.NET\n" + footer, "", true},
+		{"页脚中的短语不影响未注册域名", "example.com", "No match for \"EXAMPLE.COM\".\n" + footer, "", false},
+		{"状态字段", "example.com", "Domain Name: example.com\nDomain Status: Reserved\n" + footer, RestrictionReserved, false},
+		{"开头的提示行", "example.com", "% Registry WHOIS\n\nThis domain is blocked by DPML.\n" + footer, RestrictionBlocked, false},
+		{"空行后的提示行", "example.uk", "    Error for \"example.uk\".\n\n    This domain cannot be registered because it contravenes the Nominet UK naming rules.\n", RestrictionInvalid, false},
+		{"二级后缀使用上级后缀的规则", "example.co.uk", "    Error for \"example.co.uk\".\n\n    This domain cannot be registered because it contravenes the Nominet UK naming rules.\n", RestrictionInvalid, false},
+		{"后缀规则", "example.eu", "Domain: example.eu\nStatus: NOT AVAILABLE\n", RestrictionReserved, false},
+	}
+	for _, tt := range tests {
+		status := ParseResponse(tt.domain, tt.response)
+		if status.Restriction != tt.want || status.Registered != tt.registered {
+			t.Errorf("%s: 受限状态 %q，已注册 %t", tt.name, status.Restriction, status.Registered)
+		}
+	}
+}
+
+func TestRuleKeys(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   []string
+	}{
+		{"example.com", []string{"default", "com"}},
+		{"example.co.uk", []string{"default", "co.uk", "uk"}},
+		{"例子.公司.cn", []string{"default", "xn--55qx5d.cn", "公司.cn", "cn"}},
+	}
+	for _, tt := range tests {
+		if got := ruleKeys(tt.domain); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ruleKeys(%q) = %q，期望 %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestReloadStatusRules(t *testing.T) {
+	t.Setenv("CONFIG_DIR", t.TempDir())
+	defer ConfigureStatusRules(nil)
+
+	path := config.GetConfigPath("whois.yml")
+	response := "Domain: example.test\nStatus: Held\n"
+
+	for _, tt := range []struct {
+		content string
+		want    string
+	}{
+		{"whois_servers: {}\n", ""},
+		{"whois_servers: {}\nstatus_rules:\n  test:\n    reserved: [\"status: held\"]\n", RestrictionReserved},
+		{"whois_servers: {}\n", ""},
+	} {
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ReloadStatusRules(); err != nil {
+			t.Fatal(err)
+		}
+		if got := ParseResponse("example.test", response).Restriction; got != tt.want {
+			t.Errorf("规则 %q: 受限状态 %q，期望 %q", tt.content, got, tt.want)
+		}
+	}
+}
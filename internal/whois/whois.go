@@ -1,8 +1,18 @@
 package whois
 
 import (
+	"Puff/internal/config"
+	"Puff/internal/domainname"
+	"Puff/internal/outbound"
+	"Puff/internal/rdap"
+	"Puff/internal/registrar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,27 +25,197 @@ type DomainStatus struct {
 	Expired        bool
 	Redemption     bool
 	PendingDelete  bool
+	AutoRenew      bool
 	ExpirationDate time.Time
 	NoWhoisServer  bool
+	Restriction    string // 未注册但不能正常注册的原因：reserved、premium、blocked 或 invalid
+	Premium        bool   // 注册商标记为溢价域名，仅注册商 API 提供
+	Price          string // 注册商报出的注册价格
+	Server         string // 给出结果的服务器
+	Source         string // 结果来源的类型：whois、rdap、http 或 registrar
+	Raw            string `json:"-"` // 原始响应，用于存档和比较
 }
 
-func QueryDomain(domain, whoisServer string) (DomainStatus, error) {
-	conn, err := net.DialTimeout("tcp", whoisServer+":43", 10*time.Second)
+// 结果来源的类型
+const (
+	SourceWhois     = "whois"
+	SourceRDAP      = "rdap"
+	SourceHTTP      = "http"
+	SourceRegistrar = "registrar"
+)
+
+// HasRecord 判断 Raw 是否为 Whois 或 RDAP 注册记录，注册商 API 和 HTTP 查询的响应不包含
+// 完整的注册商、域名服务器和状态信息，不能用于字段比较
+func (s DomainStatus) HasRecord() bool {
+	return s.Raw != "" && (s.Source == SourceWhois || s.Source == SourceRDAP)
+}
+
+func sourceOf(server config.WhoisServer) string {
+	switch {
+	case server.Registrar != "":
+		return SourceRegistrar
+	case server.HTTP != nil:
+		return SourceHTTP
+	case server.IsRDAP():
+		return SourceRDAP
+	}
+	return SourceWhois
+}
+
+// 单个服务器的查询超时
+const queryTimeout = 10 * time.Second
+
+// Query 按顺序查询域名后缀的服务器列表，出错、超时或被限流时切换到下一个服务器，
+// 熔断中的服务器会被跳过
+func Query(domain string, servers []config.WhoisServer) (DomainStatus, error) {
+	available := candidates(servers)
+	if len(available) == 0 {
+		return DomainStatus{}, fmt.Errorf("%s 的 Whois 服务器均处于熔断状态", domain)
+	}
+
+	var errs []string
+	for _, server := range available {
+		status, err := queryServer(domain, server)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", server.Address(), err))
+			continue
+		}
+		return status, nil
+	}
+	return DomainStatus{}, fmt.Errorf("所有 Whois 服务器查询失败: %s", strings.Join(errs, "; "))
+}
+
+// queryServer 查询单个服务器并记录其健康统计
+func queryServer(domain string, server config.WhoisServer) (DomainStatus, error) {
+	start := time.Now()
+	status, err := fetch(domain, server)
+	record(server.Address(), time.Since(start), err)
+	status.Server = server.Address()
+	status.Source = sourceOf(server)
+	return status, err
+}
+
+// fetch 按服务器类型（注册商 API、HTTP 查询、RDAP 或 Whois）查询域名状态
+func fetch(domain string, server config.WhoisServer) (DomainStatus, error) {
+	if server.Registrar != "" {
+		result, err := registrar.Check(server.Registrar, domain)
+		if err != nil {
+			return DomainStatus{}, err
+		}
+		statuses := strings.ToLower(strings.Join(result.Statuses, " "))
+		// 注册局对不可注册域名给出的原因（如 EPP check 的 reason）按受限状态规则识别
+		var restriction string
+		if result.Available && result.Premium {
+			restriction = RestrictionPremium
+		} else if !result.Available && result.Reason != "" {
+			restriction = detectRestriction(domain, strings.ToLower(result.Reason))
+		}
+		return DomainStatus{
+			Domain:         domain,
+			Registered:     !result.Available && restriction == "",
+			Restriction:    restriction,
+			Redemption:     containsAny(statuses, redemptionPhrases()),
+			PendingDelete:  containsAny(statuses, pendingDeletePhrases()),
+			AutoRenew:      containsAny(statuses, autoRenewPhrases()),
+			ExpirationDate: result.Expiration,
+			Premium:        result.Premium,
+			Price:          result.Price,
+			Raw:            result.Raw,
+		}, nil
+	}
+
+	if server.HTTP != nil {
+		return checkHTTP(domain, *server.HTTP, server.Charset)
+	}
+
+	if server.IsRDAP() {
+		result, err := rdap.Lookup(domain, server.Host)
+		if err != nil {
+			return DomainStatus{}, err
+		}
+		return ParseResponse(domain, result.Text()), nil
+	}
+
+	response, err := queryRaw(domain, server)
 	if err != nil {
 		return DomainStatus{}, err
 	}
+	if strings.TrimSpace(response) == "" {
+		return DomainStatus{}, errors.New("服务器返回了空响应")
+	}
+	// 限流提示保留在 Raw 中，便于查询页面展示
+	if containsAny(strings.ToLower(response), throttledPhrases()) {
+		return DomainStatus{Raw: response}, errors.New("查询过于频繁，已被服务器限流")
+	}
+	return ParseResponse(domain, response), nil
+}
+
+// 部分注册局要求特殊的查询语法，服务器未配置查询模板时使用
+var defaultQueries = map[string]string{
+	"whois.denic.de": "-T dn,ace {domain}",
+	"whois.jprs.jp":  "{domain}/e",
+}
+
+// queryString 按服务器的查询模板生成查询内容
+func queryString(domain string, server config.WhoisServer) string {
+	template := server.Query
+	if template == "" {
+		template = defaultQueries[strings.ToLower(server.Host)]
+	}
+	// Whois 服务器通常只接受 A-label 形式的国际化域名
+	if template == "" {
+		return domainname.ToASCII(domain)
+	}
+	return expandTemplate(template, domain)
+}
+
+// expandTemplate 将模板中的 {domain} 替换为 A-label，{unicode} 替换为 U-label
+func expandTemplate(template, domain string) string {
+	return strings.NewReplacer(
+		"{domain}", domainname.ToASCII(domain),
+		"{unicode}", domainname.ToUnicode(domain),
+	).Replace(template)
+}
+
+// queryRaw 向 Whois 服务器发送查询，并返回解码为 UTF-8 的原始响应，之后的解析、存档和展示都基于解码后的内容
+func queryRaw(domain string, server config.WhoisServer) (string, error) {
+	port := "43"
+	if server.Port != 0 {
+		port = strconv.Itoa(server.Port)
+	}
+	query, err := encode(queryString(domain, server)+"\r\n", server.Charset)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	conn, err := outbound.DialContext(ctx, net.JoinHostPort(server.Host, port))
+	if err != nil {
+		return "", err
+	}
 	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(queryTimeout))
 
-	conn.Write([]byte(domain + "\r\n"))
+	if _, err := conn.Write(query); err != nil {
+		return "", err
+	}
 
-	var response strings.Builder
-	io.Copy(&response, conn)
+	// 部分服务器发送完响应后直接重置连接，已收到内容时不视为错误
+	var response bytes.Buffer
+	if _, err := io.Copy(&response, conn); err != nil && response.Len() == 0 {
+		return "", err
+	}
+	return decode(response.Bytes(), server.Charset, domain)
+}
 
-	responseStr := response.String()
+// ParseResponse 从 Whois 原始响应中解析域名状态
+func ParseResponse(domain, responseStr string) DomainStatus {
 	responseLower := strings.ToLower(responseStr)
 
 	status := DomainStatus{
 		Domain: domain,
+		Raw:    responseStr,
 	}
 
 	// 检查域名是否注册
@@ -47,7 +227,78 @@ func QueryDomain(domain, whoisServer string) (DomainStatus, error) {
 	// 检查域名是否处于待删除状态
 	status.PendingDelete = containsAny(responseLower, pendingDeletePhrases())
 
-	return status, nil
+	// 检查域名是否处于自动续费宽限期
+	status.AutoRenew = containsAny(responseLower, autoRenewPhrases())
+
+	// 解析到期时间
+	status.ExpirationDate = parseExpirationDate(responseStr)
+
+	// 检查是否为保留、溢价、屏蔽或无效的域名。已注册的域名有到期时间，不做识别，
+	// 以免页脚和广告中的文字造成误判
+	if status.ExpirationDate.IsZero() {
+		if status.Restriction = detectRestriction(domain, responseLower); status.Restriction != "" {
+			status.Registered = false
+			status.Redemption = false
+			status.PendingDelete = false
+		}
+	}
+
+	return status
+}
+
+// 到期时间字段
+func expirationDateKeys() []string {
+	return []string{
+		"registry expiry date",
+		"registrar registration expiration date",
+		"expiration time",
+		"expiration date",
+		"expiry date",
+		"expire date",
+		"expires on",
+		"expires",
+		"paid-till",
+		"renewal date",
+	}
+}
+
+func expirationDateLayouts() []string {
+	return []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04:05 MST",
+		"2006-01-02",
+		"2006.01.02",
+		"2006/01/02",
+		"02-Jan-2006",
+		"02.01.2006",
+		"January 02 2006",
+	}
+}
+
+func parseExpirationDate(response string) time.Time {
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		if !containsAny(key, expirationDateKeys()) {
+			continue
+		}
+
+		value := strings.TrimSpace(line[idx+1:])
+		for _, layout := range expirationDateLayouts() {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
 }
 
 // 未注册
@@ -64,6 +315,17 @@ func notRegisteredPhrases() []string {
 	}
 }
 
+// 限流
+func throttledPhrases() []string {
+	return []string{
+		"limit exceeded",
+		"quota exceeded",
+		"too many requests",
+		"rate limit",
+		"try again later",
+	}
+}
+
 // 赎回期
 func redemptionPhrases() []string {
 	return []string{
@@ -82,6 +344,15 @@ func pendingDeletePhrases() []string {
 	}
 }
 
+// 自动续费宽限期
+func autoRenewPhrases() []string {
+	return []string{
+		"autorenewperiod",
+		"auto renew period",
+		"autorenew grace",
+	}
+}
+
 func containsAny(s string, substrings []string) bool {
 	for _, substr := range substrings {
 		if strings.Contains(s, substr) {
@@ -91,7 +362,10 @@ func containsAny(s string, substrings []string) bool {
 	return false
 }
 
+// GetTLD 返回域名的公共后缀，国际化域名返回 A-label 形式（如 xn--fiqs8s）
 func GetTLD(domain string) string {
+	domain = domainname.ToASCII(domain)
+
 	// 使用 publicsuffix 库获取有效的顶级域名
 	suffix, _ := publicsuffix.PublicSuffix(domain)
 
@@ -103,3 +377,15 @@ func GetTLD(domain string) string {
 
 	return suffix
 }
+
+// FindServer 查找域名后缀对应的服务器列表，whois.yml 中的后缀可以是 A-label 或 Unicode 形式
+func FindServer(whoisServers map[string]config.ServerList, domain string) ([]config.WhoisServer, bool) {
+	tld := GetTLD(domain)
+	if servers, ok := whoisServers[tld]; ok && len(servers) > 0 {
+		return servers, true
+	}
+	if servers, ok := whoisServers[domainname.ToUnicode(tld)]; ok && len(servers) > 0 {
+		return servers, true
+	}
+	return nil, false
+}
@@ -0,0 +1,45 @@
+package whois
+
+import (
+	"Puff/internal/config"
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		server config.WhoisServer
+		want   string
+	}{
+		{"默认只发送域名", "example.com", config.WhoisServer{Host: "whois.verisign-grs.com"}, "example.com"},
+		{"国际化域名默认发送 A-label", "例子.com", config.WhoisServer{Host: "whois.verisign-grs.com"}, "xn--fsqu00a.com"},
+		{"DENIC 默认模板", "müller.de", config.WhoisServer{Host: "whois.denic.de"}, "-T dn,ace xn--mller-kva.de"},
+		{"默认模板不区分主机名大小写", "example.de", config.WhoisServer{Host: "WHOIS.DENIC.DE"}, "-T dn,ace example.de"},
+		{"JPRS 默认模板", "example.jp", config.WhoisServer{Host: "whois.jprs.jp"}, "example.jp/e"},
+		{"配置的模板优先", "example.jp", config.WhoisServer{Host: "whois.jprs.jp", Query: "{domain}"}, "example.jp"},
+		{"U-label 模板", "xn--fsqu00a.cn", config.WhoisServer{Host: "whois.cnnic.cn", Query: "{unicode}"}, "例子.cn"},
+		{"同时使用两种形式", "例子.cn", config.WhoisServer{Host: "whois.example", Query: "{domain} {unicode}"}, "xn--fsqu00a.cn 例子.cn"},
+	}
+	for _, tt := range tests {
+		if got := queryString(tt.domain, tt.server); got != tt.want {
+			t.Errorf("%s: queryString = %q，期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQueryRawSendsTemplate(t *testing.T) {
+	received := make(chan string, 1)
+	server := startWhois(t, func(query string) string {
+		received <- query
+		return "Domain Name: example\n"
+	})
+	server.Query = "{unicode}"
+
+	if _, err := queryRaw("xn--fsqu00a.cn", server); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-received, "例子.cn"; got != want {
+		t.Errorf("服务器收到 %q，期望 %q", got, want)
+	}
+}
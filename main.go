@@ -2,8 +2,12 @@ package main
 
 import (
 	"Puff/internal/config"
+	"Puff/internal/digest"
 	"Puff/internal/monitor"
+	"Puff/internal/notifier"
+	"Puff/internal/outbound"
 	"Puff/internal/web"
+	"Puff/internal/whois"
 	"log"
 	"os"
 	"os/signal"
@@ -45,11 +49,31 @@ func main() {
 		log.Fatalf("加载 Whois 服务器失败: %v", err)
 	}
 
+	// 应用 Whois 查询的出站网络设置
+	network, err := config.LoadWhoisNetwork()
+	if err != nil {
+		log.Fatalf("加载 Whois 出站网络设置失败: %v", err)
+	}
+	if err := outbound.Configure(network); err != nil {
+		log.Fatalf("应用 Whois 出站网络设置失败: %v", err)
+	}
+
+	// 加载保留、溢价等受限状态的识别规则
+	if err := whois.ReloadStatusRules(); err != nil {
+		log.Fatalf("加载域名状态规则失败: %v", err)
+	}
+
 	// 启动域名监控
 	go func() {
 		monitor.StartMonitoring(whoisServers, cfg)
 	}()
 
+	// 启动通知补发任务
+	notifier.StartDispatcher()
+
+	// 启动摘要定时任务
+	digest.StartScheduler()
+
 	// 启动 Web 服务器
 	go func() {
 		if err := web.StartServer(); err != nil {
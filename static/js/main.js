@@ -7,17 +7,37 @@ document.addEventListener('DOMContentLoaded', function() {
     if (addDomainForm) {
         addDomainForm.addEventListener('submit', function(e) {
             e.preventDefault();
-            const newDomain = document.getElementById('new-domain').value;
-            addDomain(newDomain);
+            const editing = document.getElementById('editing-domain').value;
+            if (editing) {
+                updateDomain(editing);
+            } else {
+                addDomain(document.getElementById('new-domain').value);
+            }
         });
     }
 
+    ['filter-keyword', 'filter-tag', 'filter-mode'].forEach(id => {
+        const input = document.getElementById(id);
+        if (input) {
+            input.addEventListener('input', loadDomains);
+        }
+    });
+
     if (domainList) {
         domainList.addEventListener('click', function(e) {
             if (e.target.classList.contains('delete-domain')) {
                 const domain = e.target.getAttribute('data-domain');
                 deleteDomain(domain);
             }
+            if (e.target.classList.contains('edit-domain')) {
+                const domain = e.target.getAttribute('data-domain');
+                editDomain(domain);
+            }
+            if (e.target.classList.contains('toggle-owned')) {
+                const domain = e.target.getAttribute('data-domain');
+                const owned = e.target.getAttribute('data-owned') !== 'true';
+                setDomainOwned(domain, owned);
+            }
         });
     }
 
@@ -33,16 +53,31 @@ document.addEventListener('DOMContentLoaded', function() {
         addWhoisServerForm.addEventListener('submit', function(e) {
             e.preventDefault();
             const newTLD = document.getElementById('new-tld').value;
-            const newServer = document.getElementById('new-server').value;
-            addWhoisServer(newTLD, newServer);
+            addWhoisServer(newTLD, {
+                server: document.getElementById('new-server').value,
+                port: document.getElementById('new-port').value,
+                query: document.getElementById('new-query').value,
+                charset: document.getElementById('new-charset').value
+            });
         });
     }
 
+    const whoisNetworkForm = document.getElementById('whois-network-form');
+    if (whoisNetworkForm) {
+        whoisNetworkForm.addEventListener('submit', function(e) {
+            e.preventDefault();
+            saveWhoisNetwork();
+        });
+        loadWhoisNetwork();
+    }
+
     if (whoisServerList) {
         whoisServerList.addEventListener('click', function(e) {
             if (e.target.classList.contains('delete-whois-server')) {
                 const tld = e.target.getAttribute('data-tld');
-                deleteWhoisServer(tld);
+                deleteWhoisServer(tld, e.target.getAttribute('data-server'));
+            } else if (e.target.classList.contains('move-whois-server')) {
+                moveWhoisServer(e.target.getAttribute('data-tld'), e.target.getAttribute('data-server'));
             }
         });
     }
@@ -69,6 +104,47 @@ document.addEventListener('DOMContentLoaded', function() {
     }
     checkForUpdates();
 
+    const importForm = document.getElementById('import-form');
+    if (importForm) {
+        document.getElementById('import-preview-btn').addEventListener('click', () => submitImport(false));
+        document.getElementById('import-commit-btn').addEventListener('click', () => submitImport(true));
+        importForm.addEventListener('input', () => {
+            document.getElementById('import-commit-btn').disabled = true;
+        });
+    }
+
+    const routingRulesForm = document.getElementById('routing-rules-form');
+    if (routingRulesForm) {
+        loadRoutingRules();
+        routingRulesForm.addEventListener('submit', saveRoutingRules);
+        document.getElementById('routing-test-form').addEventListener('submit', testRouting);
+    }
+
+    const registrarsForm = document.getElementById('registrars-form');
+    if (registrarsForm) {
+        loadRegistrars();
+        registrarsForm.addEventListener('submit', saveRegistrars);
+        document.getElementById('registrar-test-form').addEventListener('submit', testRegistrar);
+        loadOrders();
+        document.getElementById('auto-register-switch').addEventListener('click', toggleAutoRegister);
+    }
+
+    const lookupForm = document.getElementById('lookup-form');
+    if (lookupForm) {
+        lookupForm.addEventListener('submit', lookupDomain);
+        document.getElementById('lookup-add-btn').addEventListener('click', addLookupDomain);
+        const initial = new URLSearchParams(window.location.search).get('domain');
+        if (initial) {
+            document.getElementById('lookup-domain').value = initial;
+            lookupForm.requestSubmit();
+        }
+    }
+
+    const domainDetail = document.getElementById('domain-detail');
+    if (domainDetail) {
+        loadArchive(domainDetail.dataset.domain);
+    }
+
         const settingsForm = document.getElementById('settings-form');
     if (settingsForm) {
         forceRefreshSettings();
@@ -77,10 +153,21 @@ document.addEventListener('DOMContentLoaded', function() {
     }
 });
 
+let domainEntries = [];
+
 function loadDomains() {
-    fetch('/api/domains')
+    const params = new URLSearchParams();
+    const keyword = document.getElementById('filter-keyword');
+    const tag = document.getElementById('filter-tag');
+    const mode = document.getElementById('filter-mode');
+    if (keyword && keyword.value) params.set('q', keyword.value);
+    if (tag && tag.value) params.set('tag', tag.value);
+    if (mode && mode.value) params.set('mode', mode.value);
+
+    fetch('/api/domains?' + params.toString())
         .then(response => response.json())
         .then(domains => {
+            domainEntries = domains;
             updateDomainList(domains);
         })
         .catch(error => console.error('Error:', error));
@@ -89,30 +176,171 @@ function loadDomains() {
 function updateDomainList(domains) {
     const domainList = document.getElementById('domain-list').getElementsByTagName('tbody')[0];
     domainList.innerHTML = '';
-    domains.forEach(domain => {
+    domains.forEach(entry => {
+        const isOwned = entry.mode === 'owned';
+        const tags = (entry.tags || []).map(tag => `<span class="badge badge-outline mr-1">${tag}</span>`).join('');
         const row = document.createElement('tr');
         row.innerHTML = `
-            <td>${domain}</td>
             <td>
-                <button class="btn btn-sm delete-domain" data-domain="${domain}">删除</button>
+                <a class="link" href="/domains/${encodeURIComponent(entry.name)}">${entry.name}</a>
+                ${entry.punycode ? `<span class="text-xs text-gray-500 ml-1">${entry.punycode}</span>` : ''}
+                ${isOwned ? '<span class="badge badge-primary ml-1">自有</span>' : ''}
+                ${entry.mode === 'changes' ? '<span class="badge badge-secondary ml-1">变更监测</span>' : ''}
+                ${entry.note ? `<div class="text-xs text-gray-500">${entry.note}</div>` : ''}
+            </td>
+            <td>${tags}</td>
+            <td>
+                <button class="btn btn-sm edit-domain" data-domain="${entry.name}">编辑</button>
+                <button class="btn btn-sm toggle-owned" data-domain="${entry.name}" data-owned="${isOwned}">${isOwned ? '取消自有' : '设为自有'}</button>
+                <button class="btn btn-sm delete-domain" data-domain="${entry.name}">删除</button>
             </td>
         `;
         domainList.appendChild(row);
     });
 }
 
+function domainFormValues() {
+    return {
+        domain: document.getElementById('new-domain').value,
+        tags: document.getElementById('domain-tags').value,
+        note: document.getElementById('domain-note').value,
+        owner: document.getElementById('domain-owner').value,
+        mode: document.getElementById('domain-mode').value,
+        priority: document.getElementById('domain-priority').value,
+        check_interval: document.getElementById('domain-check-interval').value,
+        whois_server: document.getElementById('domain-whois-server').value,
+        channels: document.getElementById('domain-channels').value,
+        drop_catch: document.getElementById('domain-drop-catch').checked,
+        alert_states: document.getElementById('domain-alert-states').value,
+        expected_registrar: document.getElementById('domain-expected-registrar').value,
+        expected_name_servers: document.getElementById('domain-expected-name-servers').value,
+        expected_locks: document.getElementById('domain-expected-locks').value,
+        auto_register: document.getElementById('domain-auto-register').checked,
+        auto_register_registrar: document.getElementById('domain-auto-register-registrar').value,
+        auto_register_contact: document.getElementById('domain-auto-register-contact').value,
+        auto_register_period: document.getElementById('domain-auto-register-period').value,
+        auto_register_max_price: document.getElementById('domain-auto-register-max-price').value
+    };
+}
+
+function resetDomainForm() {
+    document.getElementById('add-domain-form').reset();
+    document.getElementById('editing-domain').value = '';
+    document.getElementById('new-domain').readOnly = false;
+    document.getElementById('add-domain-btn').textContent = '添加';
+}
+
+function editDomain(domain) {
+    const entry = domainEntries.find(e => e.name === domain);
+    if (!entry) return;
+
+    document.getElementById('editing-domain').value = entry.name;
+    document.getElementById('new-domain').value = entry.name;
+    document.getElementById('new-domain').readOnly = true;
+    document.getElementById('domain-tags').value = (entry.tags || []).join(',');
+    document.getElementById('domain-note').value = entry.note || '';
+    document.getElementById('domain-owner').value = entry.owner || '';
+    document.getElementById('domain-mode').value = entry.mode || 'watch';
+    document.getElementById('domain-priority').value = entry.priority || '';
+    document.getElementById('domain-check-interval').value = entry.check_interval || '';
+    document.getElementById('domain-whois-server').value = entry.whois_server || '';
+    document.getElementById('domain-channels').value = (entry.channels || []).join(',');
+    document.getElementById('domain-drop-catch').checked = !!entry.drop_catch;
+    document.getElementById('domain-alert-states').value = (entry.alert_states || []).join(',');
+    const expected = entry.expected || {};
+    document.getElementById('domain-expected-registrar').value = expected.registrar || '';
+    document.getElementById('domain-expected-name-servers').value = (expected.name_servers || []).join(',');
+    document.getElementById('domain-expected-locks').value = (expected.locks || []).join(',');
+    const autoRegister = entry.auto_register || {};
+    document.getElementById('domain-auto-register').checked = !!autoRegister.enabled;
+    document.getElementById('domain-auto-register-registrar').value = autoRegister.registrar || '';
+    document.getElementById('domain-auto-register-contact').value = autoRegister.contact || '';
+    document.getElementById('domain-auto-register-period').value = autoRegister.period || '';
+    document.getElementById('domain-auto-register-max-price').value = autoRegister.max_price || '';
+    document.getElementById('domain-options-toggle').checked = true;
+    document.getElementById('add-domain-btn').textContent = '保存';
+}
+
+function updateDomain(domain) {
+    const values = domainFormValues();
+    const splitList = value => value.split(',').map(v => v.trim()).filter(v => v);
+
+    fetch(`/domains/${encodeURIComponent(domain)}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            name: domain,
+            tags: splitList(values.tags),
+            note: values.note,
+            owner: values.owner,
+            mode: values.mode,
+            priority: parseInt(values.priority, 10) || 0,
+            check_interval: parseInt(values.check_interval, 10) || 0,
+            whois_server: values.whois_server,
+            channels: splitList(values.channels),
+            drop_catch: values.drop_catch,
+            alert_states: splitList(values.alert_states),
+            expected: {
+                registrar: values.expected_registrar,
+                name_servers: splitList(values.expected_name_servers),
+                locks: splitList(values.expected_locks)
+            },
+            auto_register: {
+                enabled: values.auto_register,
+                registrar: values.auto_register_registrar,
+                contact: values.auto_register_contact,
+                period: parseInt(values.auto_register_period, 10) || 0,
+                max_price: parseFloat(values.auto_register_max_price) || 0
+            }
+        })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            resetDomainForm();
+            loadDomains();
+        } else {
+            alert('保存域名失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function setDomainOwned(domain, owned) {
+    fetch(`/domains/${encodeURIComponent(domain)}/owned`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+        body: `owned=${owned}`
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadDomains();
+        } else {
+            alert('设置自有域名失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
 function addDomain(domain) {
     fetch('/domains', {
         method: 'POST',
         headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
-        body: `domain=${encodeURIComponent(domain)}`
+        body: new URLSearchParams(domainFormValues()).toString()
     })
     .then(response => response.json())
     .then(data => {
         if (data.success) {
             loadDomains(); // 重新加载域名列表
             loadDomainStatuses(); // 重新加载状态列表
-            document.getElementById('new-domain').value = '';
+            resetDomainForm();
+            if (data.domain && data.domain !== domain.trim()) {
+                alert(`已规范化为 ${data.domain} 并添加`);
+            }
+            if (data.warning) {
+                alert(data.warning);
+            }
         } else {
             alert('添加域名失败: ' + data.error);
         }
@@ -137,47 +365,553 @@ function deleteDomain(domain) {
 }
 
 
-function loadWhoisServers() {
-    fetch('/api/whois-servers')
+function submitImport(commit) {
+    const form = document.getElementById('import-form');
+    fetch(commit ? '/api/import/commit' : '/api/import/preview', {
+        method: 'POST',
+        body: new FormData(form)
+    })
+    .then(response => response.json())
+    .then(data => {
+        const container = document.getElementById('import-report');
+        if (!data.success) {
+            container.innerHTML = `<div class="alert alert-error"><span>${data.error}</span></div>`;
+            return;
+        }
+
+        const report = data.report;
+        const issues = (title, list) => list.length === 0 ? '' : `
+            <details class="collapse collapse-arrow bg-base-200">
+                <summary class="collapse-title">${title}（${list.length}）</summary>
+                <div class="collapse-content">
+                    <ul>${list.map(i => `<li>第 ${i.line} 行 ${i.input}：${i.reason}</li>`).join('')}</ul>
+                </div>
+            </details>`;
+
+        container.innerHTML = `
+            <div class="alert ${commit ? 'alert-success' : 'alert-info'}">
+                <span>${commit ? `已导入 ${data.added} 个域名` : `可导入 ${report.valid.length} 个域名`}</span>
+            </div>
+            ${commit ? '' : `
+            <details class="collapse collapse-arrow bg-base-200" open>
+                <summary class="collapse-title">可导入（${report.valid.length}）</summary>
+                <div class="collapse-content"><p class="break-all">${report.valid.map(e => e.name).join(', ')}</p></div>
+            </details>`}
+            ${issues('格式无效', report.invalid)}
+            ${issues('重复', report.duplicate)}
+            ${issues('不支持的后缀', report.unsupported)}
+        `;
+
+        const commitBtn = document.getElementById('import-commit-btn');
+        commitBtn.disabled = commit || report.valid.length === 0;
+        if (commit) {
+            form.reset();
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function loadRoutingRules() {
+    fetch('/api/routing-rules')
+        .then(response => response.json())
+        .then(data => {
+            document.getElementById('routing-rules').value = data.content || '';
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+function saveRoutingRules(e) {
+    e.preventDefault();
+    fetch('/api/routing-rules', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ content: document.getElementById('routing-rules').value })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            alert('路由规则已保存');
+        } else {
+            alert('保存路由规则失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function testRouting(e) {
+    e.preventDefault();
+    fetch('/api/routing-test', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            domain: document.getElementById('routing-test-domain').value,
+            from: document.getElementById('routing-test-from').value,
+            to: document.getElementById('routing-test-to').value
+        })
+    })
+    .then(response => response.json())
+    .then(data => {
+        const result = document.getElementById('routing-test-result');
+        if (data.error) {
+            result.textContent = '测试失败: ' + data.error;
+            return;
+        }
+        const rules = data.used_default ? '未匹配任何规则，使用默认路由' : '匹配规则：' + data.matched_rules.join('、');
+        const deliveries = (data.deliveries || []).map(d =>
+            `<li>${d.channel}：${d.recipients.length ? d.recipients.join(', ') : '（无收件人）'}</li>`
+        ).join('');
+        result.innerHTML = `
+            <p>${data.in_watchlist ? '标签：' + ((data.tags || []).join('、') || '无') : '该域名不在监控列表中，按无标签处理'}</p>
+            <p>${rules}</p>
+            <ul class="list-disc pl-5">${deliveries || '<li>不会发送到任何渠道</li>'}</ul>
+        `;
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function loadRegistrars() {
+    fetch('/api/registrars')
         .then(response => response.json())
-        .then(servers => {
-            updateWhoisServerList(servers);
+        .then(data => {
+            document.getElementById('registrars-content').value = data.content || '';
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+function saveRegistrars(e) {
+    e.preventDefault();
+    fetch('/api/registrars', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ content: document.getElementById('registrars-content').value })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            alert('注册商配置已保存');
+        } else {
+            alert('保存注册商配置失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function testRegistrar(e) {
+    e.preventDefault();
+    const result = document.getElementById('registrar-test-result');
+    result.textContent = '查询中...';
+    fetch('/api/registrars/test', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            name: document.getElementById('registrar-test-name').value.trim(),
+            domain: document.getElementById('registrar-test-domain').value.trim()
+        })
+    })
+    .then(response => response.json())
+    .then(data => {
+        result.innerHTML = '';
+        if (data.error) {
+            result.textContent = '查询失败: ' + data.error;
+            return;
+        }
+        const summary = document.createElement('p');
+        summary.textContent = [
+            `${data.domain}：${data.available ? '可注册' : '不可注册'}`,
+            data.premium ? '溢价域名' : '',
+            data.reason ? `原因 ${data.reason}` : '',
+            data.price ? `价格 ${data.price}` : ''
+        ].filter(s => s).join('，');
+        const raw = document.createElement('pre');
+        raw.className = 'bg-base-200 p-2 mt-2 overflow-x-auto text-xs whitespace-pre-wrap';
+        raw.textContent = data.raw;
+        result.append(summary, raw);
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+const orderStatusText = {
+    succeeded: '成功',
+    pending: '已提交',
+    failed: '失败',
+    skipped: '未下单'
+};
+
+function loadOrders() {
+    fetch('/api/orders')
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                console.error('加载订单记录失败:', data.error);
+                return;
+            }
+            document.getElementById('auto-register-summary').textContent =
+                `总开关：${data.enabled ? '开启' : '关闭'}，已花费 ${data.spent} / 上限 ${data.budget}`;
+            const btn = document.getElementById('auto-register-switch');
+            btn.textContent = data.enabled ? '关闭自动注册' : '开启自动注册';
+            btn.dataset.enabled = data.enabled;
+            btn.classList.toggle('btn-error', data.enabled);
+
+            const tbody = document.querySelector('#order-list tbody');
+            tbody.innerHTML = '';
+            data.orders.slice().reverse().forEach(order => {
+                const row = document.createElement('tr');
+                [
+                    new Date(order.at).toLocaleString(),
+                    order.domain,
+                    order.registrar,
+                    order.period,
+                    orderStatusText[order.status] || order.status,
+                    order.cost,
+                    order.order_id || order.error || ''
+                ].forEach(value => {
+                    const cell = document.createElement('td');
+                    cell.textContent = value;
+                    row.appendChild(cell);
+                });
+                tbody.appendChild(row);
+            });
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+function toggleAutoRegister() {
+    const enabled = this.dataset.enabled !== 'true';
+    if (enabled && !confirm('开启后，设置了自动注册的域名确认可注册时会立即下单扣费，确定开启吗？')) {
+        return;
+    }
+    fetch('/api/orders/switch', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ enabled })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadOrders();
+        } else {
+            alert('切换自动注册失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function lookupDomain(e) {
+    e.preventDefault();
+    const domain = document.getElementById('lookup-domain').value.trim();
+    const btn = document.getElementById('lookup-btn');
+    btn.disabled = true;
+    btn.textContent = '查询中...';
+
+    fetch(`/api/lookup/${encodeURIComponent(domain)}`)
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                alert('查询失败: ' + data.error);
+                return;
+            }
+
+            document.getElementById('lookup-result').classList.remove('hidden');
+            document.getElementById('lookup-name').textContent =
+                data.punycode !== data.domain ? `${data.domain}（${data.punycode}）` : data.domain;
+            document.getElementById('lookup-status').textContent = data.status_text;
+            const expiry = new Date(data.status.ExpirationDate);
+            document.getElementById('lookup-expiry').textContent =
+                expiry.getFullYear() > 1 ? expiry.toLocaleString() : '未知';
+            document.getElementById('lookup-server').textContent = data.server;
+            document.getElementById('lookup-duration').textContent = `${data.duration_ms} ms`;
+
+            const addBtn = document.getElementById('lookup-add-btn');
+            addBtn.dataset.domain = data.domain;
+            addBtn.disabled = data.watched;
+            addBtn.textContent = data.watched ? '已在监控列表中' : '加入监控列表';
+
+            // 原始响应使用 textContent 填充，避免响应内容被当作 HTML 解析
+            const hops = document.getElementById('lookup-hops');
+            hops.innerHTML = '';
+            data.hops.forEach((hop, i) => {
+                const details = document.createElement('details');
+                details.className = 'collapse collapse-arrow bg-base-200';
+                details.open = i === 0;
+                const summary = document.createElement('summary');
+                summary.className = 'collapse-title';
+                summary.textContent = `${i === 0 ? '注册局' : '转介'} ${hop.server}（${hop.duration_ms} ms）${hop.error ? '：' + hop.error : ''}`;
+                const pre = document.createElement('pre');
+                pre.className = 'collapse-content text-xs whitespace-pre-wrap break-all';
+                pre.textContent = hop.response;
+                details.appendChild(summary);
+                details.appendChild(pre);
+                hops.appendChild(details);
+            });
+        })
+        .catch(error => console.error('Error:', error))
+        .finally(() => {
+            btn.disabled = false;
+            btn.textContent = '查询';
+        });
+}
+
+function addLookupDomain() {
+    const btn = document.getElementById('lookup-add-btn');
+    fetch('/domains', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+        body: new URLSearchParams({ domain: btn.dataset.domain }).toString()
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            btn.disabled = true;
+            btn.textContent = '已在监控列表中';
+            if (data.warning) {
+                alert(data.warning);
+            }
+        } else {
+            alert('添加域名失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function loadArchive(domain) {
+    fetch(`/api/domains/${encodeURIComponent(domain)}/archive`)
+        .then(response => response.json())
+        .then(data => {
+            const tbody = document.getElementById('archive-list').getElementsByTagName('tbody')[0];
+            tbody.innerHTML = '';
+            if (data.error) {
+                tbody.innerHTML = `<tr><td colspan="5">${data.error}</td></tr>`;
+                return;
+            }
+            if (data.snapshots.length === 0) {
+                tbody.innerHTML = '<tr><td colspan="5">暂无存档</td></tr>';
+                return;
+            }
+
+            // 最新的存档显示在最前面，只与同一服务器的上一份比较
+            data.snapshots.slice().reverse().forEach((snapshot, i, list) => {
+                const previous = list.slice(i + 1).find(s => (s.server || '') === (snapshot.server || ''));
+                const row = document.createElement('tr');
+                row.innerHTML = `
+                    <td>${new Date(snapshot.first_seen).toLocaleString()}</td>
+                    <td>${new Date(snapshot.last_seen).toLocaleString()}</td>
+                    <td>${snapshot.count}</td>
+                    <td>${snapshot.server || '-'}</td>
+                    <td>
+                        <button class="btn btn-sm view-archive">查看</button>
+                        ${previous ? '<button class="btn btn-sm diff-archive">与上一份比较</button>' : ''}
+                    </td>
+                `;
+                row.querySelector('.view-archive').addEventListener('click', () => showArchive(domain, snapshot.hash));
+                if (previous) {
+                    row.querySelector('.diff-archive').addEventListener('click', () => showArchiveDiff(domain, previous.hash, snapshot.hash));
+                }
+                tbody.appendChild(row);
+            });
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+function showArchive(domain, hash) {
+    fetch(`/api/domains/${encodeURIComponent(domain)}/archive/${hash}`)
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                alert(data.error);
+                return;
+            }
+            document.getElementById('archive-changes').innerHTML = '';
+            const pre = document.getElementById('archive-diff');
+            pre.classList.remove('hidden');
+            pre.textContent = data.content;
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+function showArchiveDiff(domain, from, to) {
+    fetch(`/api/domains/${encodeURIComponent(domain)}/diff?from=${from}&to=${to}`)
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                alert(data.error);
+                return;
+            }
+
+            const changes = document.getElementById('archive-changes');
+            changes.innerHTML = '';
+            (data.changes || []).forEach(change => {
+                const p = document.createElement('p');
+                p.className = 'font-semibold';
+                p.textContent = change;
+                changes.appendChild(p);
+            });
+
+            // 差异内容使用 textContent 填充，避免响应内容被当作 HTML 解析
+            const pre = document.getElementById('archive-diff');
+            pre.classList.remove('hidden');
+            pre.innerHTML = '';
+            data.lines.forEach(line => {
+                const span = document.createElement('span');
+                if (line.op === '+') span.className = 'bg-success/30';
+                if (line.op === '-') span.className = 'bg-error/30';
+                span.textContent = `${line.op} ${line.text}\n`;
+                pre.appendChild(span);
+            });
+        })
+        .catch(error => console.error('Error:', error));
+}
+
+let whoisServerConfig = {};
+
+function loadWhoisServers() {
+    Promise.all([
+        fetch('/api/whois-servers').then(response => response.json()),
+        fetch('/api/whois-servers/stats').then(response => response.json())
+    ])
+        .then(([servers, stats]) => {
+            whoisServerConfig = servers;
+            updateWhoisServerList(servers, stats);
         })
         .catch(error => {
             console.error('Error loading Whois servers:', error);
         });
 }
 
-function updateWhoisServerList(servers) {
+const whoisServerStateText = {
+    healthy: '正常',
+    degraded: '降级',
+    open: '熔断'
+};
+
+function updateWhoisServerList(servers, stats) {
     const serverList = document.getElementById('whois-server-list').getElementsByTagName('tbody')[0];
     if (!serverList) return;
 
     serverList.innerHTML = '';
-    for (const [tld, server] of Object.entries(servers)) {
-        const row = document.createElement('tr');
-        row.innerHTML = `
-            <td>${tld}</td>
-            <td>${server}</td>
-            <td>
-                <button class="btn  btn-sm delete-whois-server" data-tld="${tld}">删除</button>
-            </td>
-        `;
-        serverList.appendChild(row);
+    for (const [tld, list] of Object.entries(servers)) {
+        list.forEach((server, index) => {
+            const address = whoisServerAddress(server);
+            const stat = stats[address];
+            const row = document.createElement('tr');
+            const options = [];
+            if (server.http) options.push(`HTTP ${server.http.method || 'GET'} 查询，${server.http.rules.length} 条规则`);
+            if (server.query) options.push(`查询: ${server.query}`);
+            if (server.charset) options.push(`编码: ${server.charset}`);
+            const cells = [
+                index === 0 ? tld : '',
+                options.length > 0 ? `${address}（${options.join('，')}）` : address,
+                stat ? whoisServerStateText[stat.state] : '未使用',
+                stat ? `${stat.successes} / ${stat.failures}` : '-',
+                stat && stat.successes > 0 ? `${stat.avg_latency_ms} ms` : '-',
+                stat && stat.last_error ? stat.last_error : '-'
+            ];
+            cells.forEach(text => {
+                const td = document.createElement('td');
+                td.textContent = text;
+                row.appendChild(td);
+            });
+            if (stat && stat.state === 'open') {
+                row.children[2].title = `熔断至 ${new Date(stat.open_until).toLocaleString()}`;
+            }
+
+            const actions = document.createElement('td');
+            if (index > 0) {
+                actions.appendChild(whoisServerButton('上移', 'move-whois-server', tld, address));
+            }
+            actions.appendChild(whoisServerButton('删除', 'delete-whois-server', tld, address));
+            row.appendChild(actions);
+            serverList.appendChild(row);
+        });
     }
 }
 
+// whoisServerAddress 与后端的 WhoisServer.Address 一致，非默认端口时附加端口号
+function whoisServerAddress(server) {
+    if (server.http) return server.http.url;
+    const isRDAP = server.host.startsWith('http://') || server.host.startsWith('https://');
+    if (isRDAP || !server.port || server.port === 43) return server.host;
+    return server.host.includes(':') ? `[${server.host}]:${server.port}` : `${server.host}:${server.port}`;
+}
+
+function whoisServerButton(text, className, tld, server) {
+    const button = document.createElement('button');
+    button.className = `btn btn-sm mr-1 ${className}`;
+    button.textContent = text;
+    button.setAttribute('data-tld', tld);
+    button.setAttribute('data-server', server);
+    return button;
+}
+
+function moveWhoisServer(tld, server) {
+    const order = (whoisServerConfig[tld] || []).map(whoisServerAddress);
+    const index = order.indexOf(server);
+    if (index <= 0) return;
+    [order[index - 1], order[index]] = [order[index], order[index - 1]];
+
+    fetch(`/whois-servers/${encodeURIComponent(tld)}`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ servers: order })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadWhoisServers();
+        } else {
+            alert('调整服务器顺序失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
+function loadWhoisNetwork() {
+    fetch('/api/whois-network')
+        .then(response => response.json())
+        .then(network => {
+            document.getElementById('network-proxy').value = network.proxy || '';
+            document.getElementById('network-sources').value = (network.source_addresses || []).join('\n');
+            document.getElementById('network-selection').value = network.selection || 'round-robin';
+        })
+        .catch(error => console.error('Error loading Whois network:', error));
+}
+
+function saveWhoisNetwork() {
+    const network = {
+        proxy: document.getElementById('network-proxy').value,
+        source_addresses: document.getElementById('network-sources').value.split('\n').map(s => s.trim()).filter(s => s),
+        selection: document.getElementById('network-selection').value
+    };
+
+    fetch('/api/whois-network', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(network)
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            alert('出站网络设置已保存');
+        } else {
+            alert('保存出站网络设置失败: ' + data.error);
+        }
+    })
+    .catch(error => console.error('Error:', error));
+}
+
 function addWhoisServer(tld, server) {
+    const params = new URLSearchParams({ tld, ...server });
     fetch('/whois-servers', {
         method: 'POST',
         headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
-        body: `tld=${encodeURIComponent(tld)}&server=${encodeURIComponent(server)}`
+        body: params.toString()
     })
     .then(response => response.json())
     .then(data => {
         if (data.success) {
             loadWhoisServers();
-            document.getElementById('new-tld').value = '';
-            document.getElementById('new-server').value = '';
+            ['new-tld', 'new-server', 'new-port', 'new-query', 'new-charset'].forEach(id => {
+                document.getElementById(id).value = '';
+            });
         } else {
             alert('添加 Whois 服务器失败: ' + data.error);
         }
@@ -185,8 +919,8 @@ function addWhoisServer(tld, server) {
     .catch(error => console.error('Error:', error));
 }
 
-function deleteWhoisServer(tld) {
-    fetch(`/whois-servers/${encodeURIComponent(tld)}`, { 
+function deleteWhoisServer(tld, server) {
+    fetch(`/whois-servers/${encodeURIComponent(tld)}?server=${encodeURIComponent(server || '')}`, { 
         method: 'DELETE' 
     })
     .then(response => response.json())
@@ -246,6 +980,20 @@ document.addEventListener('DOMContentLoaded', function() {
 
 let currentSort = { column: null, direction: 'asc' };
 
+const dnsResultText = {
+    delegated: '已委派',
+    nxdomain: 'NXDOMAIN',
+    undelegated: '未委派',
+    unknown: '查询失败'
+};
+
+const restrictionText = {
+    reserved: '保留',
+    premium: '溢价',
+    blocked: '屏蔽',
+    invalid: '无效'
+};
+
 function updateDomainStatusList(statuses) {
     const statusTableBody = document.getElementById('status-table-body');
     if (!statusTableBody) return;
@@ -261,7 +1009,11 @@ function updateDomainStatusList(statuses) {
             monitorStatus = '等待监控';
         } else {
             // 使用 if-else 链来确定状态，确保只有一个状态被选中
-            if (status.PendingDelete) {
+            if (status.PendingConfirmation) {
+                statusText = '<span class="text-yellow-600">可注册（待确认）</span>';
+            } else if (status.Restriction) {
+                statusText = `<span class="text-purple-600">${restrictionText[status.Restriction] || status.Restriction}</span>`;
+            } else if (status.PendingDelete) {
                 statusText = '<span class="text-red-500">待删除</span>';
             } else if (status.Redemption) {
                 statusText = '<span class="text-orange-500">赎回期</span>';
@@ -273,9 +1025,21 @@ function updateDomainStatusList(statuses) {
             lastCheckedTime = new Date(status.LastChecked).toLocaleString();
             monitorStatus = status.CheckCount < 3 ? '正在监控' : '已通知';
         }
+        if (status.DNSResult) {
+            statusText += `<div class="text-xs text-gray-500">DNS：${dnsResultText[status.DNSResult] || status.DNSResult}</div>`;
+        }
+        if (status.DropCatching) {
+            statusText += '<div class="text-xs text-red-500">抢注轮询中</div>';
+        }
+        if (status.Premium || status.Price) {
+            const price = document.createElement('div');
+            price.className = 'text-xs text-purple-600';
+            price.textContent = [status.Premium ? '溢价域名' : '', status.Price].filter(s => s).join('，');
+            statusText += price.outerHTML;
+        }
 
         row.innerHTML = `
-            <td>${status.Domain}</td>
+            <td>${status.Domain}${status.Punycode ? `<div class="text-xs text-gray-500">${status.Punycode}</div>` : ''}</td>
             <td>${statusText}</td>
             <td>${lastCheckedTime}</td>
             <td>${monitorStatus}</td>
@@ -375,6 +1139,22 @@ function saveSettings(e) {
     if ('SMTP_PORT' in settings) settings.SMTP_PORT = parseInt(settings.SMTP_PORT, 10) || 0;
     if ('WEB_PORT' in settings) settings.WEB_PORT = parseInt(settings.WEB_PORT, 10) || 0;
     if ('QUERY_FREQUENCY_SECONDS' in settings) settings.QUERY_FREQUENCY_SECONDS = parseInt(settings.QUERY_FREQUENCY_SECONDS, 10) || 0;
+    if ('DIGEST_WEEKDAY' in settings) settings.DIGEST_WEEKDAY = parseInt(settings.DIGEST_WEEKDAY, 10) || 0;
+    if ('EMAIL_MAX_PER_HOUR' in settings) settings.EMAIL_MAX_PER_HOUR = parseInt(settings.EMAIL_MAX_PER_HOUR, 10) || 0;
+    if ('NOTIFY_DEDUPE_MINUTES' in settings) settings.NOTIFY_DEDUPE_MINUTES = parseInt(settings.NOTIFY_DEDUPE_MINUTES, 10) || 0;
+    if ('NOTIFY_URGENT_FINAL' in settings) settings.NOTIFY_URGENT_FINAL = settings.NOTIFY_URGENT_FINAL === 'true';
+    if ('NOTIFY_FIELD_CHANGES' in settings) settings.NOTIFY_FIELD_CHANGES = settings.NOTIFY_FIELD_CHANGES === 'true';
+    if ('DNS_PREFILTER' in settings) settings.DNS_PREFILTER = settings.DNS_PREFILTER === 'true';
+    ['CONFIRM_REQUIRED', 'CONFIRM_CONSECUTIVE', 'CONFIRM_WINDOW_MINUTES'].forEach(key => {
+        if (key in settings) settings[key] = parseInt(settings[key], 10) || 0;
+    });
+    if ('DNS_WHOIS_EVERY' in settings) settings.DNS_WHOIS_EVERY = parseInt(settings.DNS_WHOIS_EVERY, 10) || 0;
+    if ('ARCHIVE_RETENTION_DAYS' in settings) settings.ARCHIVE_RETENTION_DAYS = parseInt(settings.ARCHIVE_RETENTION_DAYS, 10) || 0;
+    if ('AUTO_REGISTER_ENABLED' in settings) settings.AUTO_REGISTER_ENABLED = settings.AUTO_REGISTER_ENABLED === 'true';
+    if ('AUTO_REGISTER_BUDGET' in settings) settings.AUTO_REGISTER_BUDGET = parseFloat(settings.AUTO_REGISTER_BUDGET) || 0;
+    ['DROP_CATCH_INTERVAL_MS', 'DROP_CATCH_RATE', 'DROP_CATCH_WINDOW_HOURS'].forEach(key => {
+        if (key in settings) settings[key] = parseInt(settings[key], 10) || 0;
+    });
 
     fetch('/api/settings', {
         method: 'POST',
@@ -413,8 +1193,16 @@ function updateSettingsForm(data) {
     const config = data.config || {};
 
     const fields = [
-        'RECIPIENT_EMAIL', 'SMTP_SERVER', 'SMTP_PORT', 'SMTP_USERNAME', 'SMTP_PASSWORD',
-        'WEB_PORT', 'AUTH_USERNAME', 'AUTH_PASSWORD', 'QUERY_FREQUENCY_SECONDS', 'SESSION_SECRET'
+        'RECIPIENT_EMAIL', 'SMTP_CC', 'SMTP_BCC', 'SMTP_FROM_NAME', 'SMTP_REPLY_TO',
+        'SMTP_SERVER', 'SMTP_PORT', 'SMTP_USERNAME', 'SMTP_PASSWORD', 'SMTP_AUTH_METHOD', 'SMTP_TLS_MODE',
+        'WEB_PORT', 'AUTH_USERNAME', 'AUTH_PASSWORD', 'QUERY_FREQUENCY_SECONDS', 'SESSION_SECRET',
+        'DIGEST_FREQUENCY', 'DIGEST_TIME', 'DIGEST_TIMEZONE', 'DIGEST_WEEKDAY', 'DIGEST_RECIPIENTS',
+        'EMAIL_QUIET_HOURS', 'EMAIL_MAX_PER_HOUR', 'NOTIFY_TIMEZONE', 'NOTIFY_DEDUPE_MINUTES', 'NOTIFY_URGENT_FINAL',
+        'EXPIRY_REMINDER_DAYS', 'NOTIFY_FIELD_CHANGES', 'ARCHIVE_RETENTION_DAYS',
+        'DNS_PREFILTER', 'DNS_RESOLVERS', 'DNS_WHOIS_EVERY',
+        'CONFIRM_SOURCES', 'CONFIRM_REQUIRED', 'CONFIRM_CONSECUTIVE', 'CONFIRM_WINDOW_MINUTES',
+        'AUTO_REGISTER_ENABLED', 'AUTO_REGISTER_BUDGET',
+        'DROP_CATCH_INTERVAL_MS', 'DROP_CATCH_RATE', 'DROP_CATCH_WINDOW_HOURS'
     ];
 
     fields.forEach(field => {